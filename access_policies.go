@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+	"golang.org/x/net/context"
+)
+
+// accessPolicies is the matrix of the restricted fields, giving for each field path, such as topper or
+// topper.headline, the access policies allowed to see it. The fields of the array elements have the path of the
+// array, such as embeds.title. The fields which are not listed are seen by every caller.
+type accessPolicies map[string][]string
+
+func parseAccessPolicies(policies string) (accessPolicies, error) {
+	var parsed accessPolicies
+	if policies == "" {
+		return parsed, nil
+	}
+	if err := json.Unmarshal([]byte(policies), &parsed); err != nil {
+		return nil, err
+	}
+	for path := range parsed {
+		if _, err := fieldPathsFilter([]string{path}); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+// parsePolicyHeader parses the comma separated access policies of the policy header, such as INTERNAL_UNSTABLE.
+func parsePolicyHeader(header string) map[string]bool {
+	return parseFieldPaths(header)
+}
+
+// deniedFields returns the sorted paths of the restricted fields of the content that none of the policies is allowed to see.
+func (m accessPolicies) deniedFields(content map[string]interface{}, policies map[string]bool) []string {
+	var denied []string
+	for path, allowed := range m {
+		if !hasField(content, path) || anyPolicy(allowed, policies) {
+			continue
+		}
+		denied = append(denied, path)
+	}
+	sort.Strings(denied)
+	return denied
+}
+
+func anyPolicy(allowed []string, policies map[string]bool) bool {
+	for _, p := range allowed {
+		if policies[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasField tells whether the content holds the field of the path, going through the nested objects and arrays.
+func hasField(content map[string]interface{}, path string) bool {
+	return hasPath(content, strings.Split(path, "."))
+}
+
+func hasPath(value interface{}, keys []string) bool {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		next, found := typedValue[keys[0]]
+		if !found {
+			return false
+		}
+		return len(keys) == 1 || hasPath(next, keys[1:])
+	case []interface{}:
+		for _, elem := range typedValue {
+			if hasPath(elem, keys) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// restrictFields returns the content without the restricted fields the access policies of the request
+// are not allowed to see, logging the denials for audit.
+func (h internalContentHandler) restrictFields(ctx context.Context, r *http.Request, content map[string]interface{}) map[string]interface{} {
+	if len(h.serviceConfig.accessPolicies) == 0 {
+		return content
+	}
+	policies := parsePolicyHeader(r.Header.Get(h.serviceConfig.accessPolicyHeader))
+	denied := h.serviceConfig.accessPolicies.deniedFields(content, policies)
+	if len(denied) == 0 {
+		return content
+	}
+	transactionID, _ := transactionidutils.GetTransactionIDFromContext(ctx)
+	h.log.AccessDeniedEvent(r.RequestURI, transactionID, contentUUID(ctx), sortedPolicies(policies), denied)
+	filter, err := fieldPathsFilter(denied)
+	if err != nil {
+		// the paths were validated when the policies were parsed
+		panic(fmt.Sprintf("invalid access policy paths %v: %v", denied, err))
+	}
+	return filterKeys(content, filter)
+}
+
+func sortedPolicies(policies map[string]bool) []string {
+	sorted := make([]string, 0, len(policies))
+	for p := range policies {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
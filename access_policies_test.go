@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestParseAccessPolicies(t *testing.T) {
+	policies, err := parseAccessPolicies(`{"topper": ["INTERNAL_UNSTABLE"], "alternativeTitles.promotionalTitle": ["INTERNAL_UNSTABLE", "PROMOTION"]}`)
+	require.NoError(t, err)
+	assert.Equal(t, accessPolicies{"topper": {"INTERNAL_UNSTABLE"}, "alternativeTitles.promotionalTitle": {"INTERNAL_UNSTABLE", "PROMOTION"}}, policies)
+
+	_, err = parseAccessPolicies(`{"topper.": ["INTERNAL_UNSTABLE"]}`)
+	assert.Error(t, err)
+	_, err = parseAccessPolicies(`["topper"]`)
+	assert.Error(t, err)
+}
+
+func TestDeniedFields(t *testing.T) {
+	policies := accessPolicies{
+		"topper":                             {"INTERNAL_UNSTABLE"},
+		"publishReference":                   {"INTERNAL_UNSTABLE", "PUBLISHING"},
+		"alternativeTitles.promotionalTitle": {"INTERNAL_UNSTABLE"},
+		"design":                             {"INTERNAL_UNSTABLE"},
+	}
+	content := map[string]interface{}{
+		"title":             "Title",
+		"topper":            map[string]interface{}{"headline": "Headline"},
+		"publishReference":  "tid_1",
+		"alternativeTitles": map[string]interface{}{"promotionalTitle": "Promotional"},
+	}
+
+	assert.Equal(t, []string{"alternativeTitles.promotionalTitle", "publishReference", "topper"}, policies.deniedFields(content, nil))
+	assert.Equal(t, []string{"alternativeTitles.promotionalTitle", "topper"}, policies.deniedFields(content, parsePolicyHeader("PUBLISHING")))
+	assert.Empty(t, policies.deniedFields(content, parsePolicyHeader("PUBLISHING, INTERNAL_UNSTABLE")))
+}
+
+func TestAccessPoliciesGoThroughArrays(t *testing.T) {
+	policies, err := parseAccessPolicies(`{"embeds.title": ["INTERNAL_UNSTABLE"], "leadImages.image": ["INTERNAL_UNSTABLE"]}`)
+	require.NoError(t, err)
+	content := map[string]interface{}{
+		"title": "Title",
+		"embeds": []interface{}{
+			map[string]interface{}{"id": "http://api.ft.com/content/1", "title": "Embed"},
+			"http://api.ft.com/content/2",
+		},
+		"leadImages": []interface{}{map[string]interface{}{"id": "http://api.ft.com/content/3"}},
+	}
+
+	assert.Equal(t, []string{"embeds.title"}, policies.deniedFields(content, nil))
+
+	h := internalContentHandler{serviceConfig: &serviceConfig{accessPolicies: policies, accessPolicyHeader: "X-Policy"}, log: newAppLogger()}
+	ctx := context.WithValue(context.Background(), uuidKey, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+	r := httptest.NewRequest(http.MethodGet, "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", nil)
+	assert.Equal(t, map[string]interface{}{
+		"title": "Title",
+		"embeds": []interface{}{
+			map[string]interface{}{"id": "http://api.ft.com/content/1"},
+			"http://api.ft.com/content/2",
+		},
+		"leadImages": []interface{}{map[string]interface{}{"id": "http://api.ft.com/content/3"}},
+	}, h.restrictFields(ctx, r, content))
+	assert.Equal(t, "Embed", content["embeds"].([]interface{})[0].(map[string]interface{})["title"], "The content should be left unchanged")
+}
+
+func TestRestrictFieldsLogsTheDenials(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	policies, err := parseAccessPolicies(`{"topper": ["INTERNAL_UNSTABLE"], "alternativeTitles.promotionalTitle": ["INTERNAL_UNSTABLE"]}`)
+	require.NoError(t, err)
+	h := internalContentHandler{serviceConfig: &serviceConfig{accessPolicies: policies, accessPolicyHeader: "X-Policy"}, log: &appLogger{logger}}
+	ctx := context.WithValue(context.Background(), uuidKey, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+	content := map[string]interface{}{
+		"title":             "Title",
+		"topper":            map[string]interface{}{"headline": "Headline"},
+		"alternativeTitles": map[string]interface{}{"promotionalTitle": "Promotional", "contentPackageTitle": "Package"},
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", nil)
+	r.Header.Set("X-Policy", "PARTNER")
+	restricted := h.restrictFields(ctx, r, content)
+
+	assert.Equal(t, map[string]interface{}{
+		"title":             "Title",
+		"alternativeTitles": map[string]interface{}{"contentPackageTitle": "Package"},
+	}, restricted)
+	assert.Contains(t, content, "topper", "The content should be left unchanged")
+	require.Len(t, hook.Entries, 1)
+	entry := hook.LastEntry()
+	assert.Equal(t, logrus.InfoLevel, entry.Level)
+	assert.Equal(t, "access_denied", entry.Data["event"])
+	assert.Equal(t, []string{"PARTNER"}, entry.Data["policies"])
+	assert.Equal(t, []string{"alternativeTitles.promotionalTitle", "topper"}, entry.Data["denied_fields"])
+	assert.Equal(t, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", entry.Data["uuid"])
+
+	hook.Reset()
+	r.Header.Set("X-Policy", "PARTNER, INTERNAL_UNSTABLE")
+	assert.Equal(t, content, h.restrictFields(ctx, r, content))
+	assert.Empty(t, hook.Entries, "Nothing should be logged when nothing is denied")
+}
+
+func TestVaryHeaderListsTheAccessPolicyHeader(t *testing.T) {
+	h := internalContentHandler{serviceConfig: &serviceConfig{accessPolicies: accessPolicies{"topper": {"INTERNAL_UNSTABLE"}}, accessPolicyHeader: "X-Policy"}}
+	assert.Equal(t, "Accept, X-Policy", h.varyHeader())
+
+	h = internalContentHandler{serviceConfig: &serviceConfig{}}
+	assert.Equal(t, "Accept", h.varyHeader())
+}
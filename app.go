@@ -52,6 +52,24 @@ func main() {
 		Desc:   "Cache control policy header",
 		EnvVar: "CACHE_CONTROL_POLICY",
 	})
+	cacheControlRules := app.String(cli.StringOpt{
+		Name:   "cache-control-rules",
+		Value:  "",
+		Desc:   "JSON list of rules choosing the Cache-Control policy by content age, types and partial responses. The first matching rule wins, falling back to the cache control policy",
+		EnvVar: "CACHE_CONTROL_RULES",
+	})
+	surrogateControlPolicy := app.String(cli.StringOpt{
+		Name:   "surrogate-control-policy",
+		Value:  "",
+		Desc:   "Surrogate control policy header for the CDN, not set when empty",
+		EnvVar: "SURROGATE_CONTROL_POLICY",
+	})
+	goneCacheControlPolicy := app.String(cli.StringOpt{
+		Name:   "gone-cache-control-policy",
+		Value:  "max-age=60",
+		Desc:   "Cache control policy header of the 410 responses for deleted content",
+		EnvVar: "GONE_CACHE_CONTROL_POLICY",
+	})
 	contentSourceURI := app.String(cli.StringOpt{
 		Name:   "content-source-uri",
 		Value:  "http://localhost:8080/__enriched-content-read-api/enrichedcontent/",
@@ -70,6 +88,12 @@ func main() {
 		Desc:   "Service name of the content source application",
 		EnvVar: "CONTENT_SOURCE_APP_NAME",
 	})
+	contentFallbackSources := app.String(cli.StringOpt{
+		Name:   "content-fallback-sources",
+		Value:  "",
+		Desc:   "JSON list of the appName and appURI of the sources tried in order when the content source does not find or fails to provide the content",
+		EnvVar: "CONTENT_FALLBACK_SOURCES",
+	})
 	internalComponentsSourceAppName := app.String(cli.StringOpt{
 		Name:   "internal-components-source-app-name",
 		Value:  "Internal Components Source Service",
@@ -142,12 +166,120 @@ func main() {
 		Desc:   "Describe the business impact the content unroller app would produce if it is broken.",
 		EnvVar: "CONTENT_UNROLLER_APP_BUSINESS_IMPACT",
 	})
+	identifierResolverURI := app.String(cli.StringOpt{
+		Name:   "identifier-resolver-uri",
+		Value:  "",
+		Desc:   "URI resolving alternate identifiers and web URLs to content uuids. Lookup by identifier is disabled when empty",
+		EnvVar: "IDENTIFIER_RESOLVER_URI",
+	})
+	identifierResolverAppName := app.String(cli.StringOpt{
+		Name:   "identifier-resolver-app-name",
+		Value:  "Identifier Resolver Service",
+		Desc:   "Service name of the identifier resolver application",
+		EnvVar: "IDENTIFIER_RESOLVER_APP_NAME",
+	})
 	envAPIHost := app.String(cli.StringOpt{
 		Name:   "env-api-host",
 		Value:  "api.ft.com",
 		Desc:   "API host to use for URLs in responses",
 		EnvVar: "ENV_API_HOST",
 	})
+	readingMetadata := app.Bool(cli.BoolOpt{
+		Name:   "reading-metadata",
+		Value:  false,
+		Desc:   "Whether to add the reading metadata fields (wordCount, readingTimeMinutes, imageCount, embedCount, summaryText) to every response",
+		EnvVar: "READING_METADATA",
+	})
+	summaryLength := app.Int(cli.IntOpt{
+		Name:   "summary-length",
+		Value:  200,
+		Desc:   "Maximum number of characters of the summaryText reading metadata field",
+		EnvVar: "SUMMARY_LENGTH",
+	})
+	sourceFilters := app.String(cli.StringOpt{
+		Name:   "source-filters",
+		Value:  "",
+		Desc:   `JSON object of the fields removed from the content and from the embeds of each source, keyed by source app name, e.g. {"content-public-read": {"fields": ["id", "uuid", "lastModified", "publishReference", "topper.internalNote"], "embeds": ["requestUrl"]}}`,
+		EnvVar: "SOURCE_FILTERS",
+	})
+	responseProfiles := app.String(cli.StringOpt{
+		Name:   "response-profiles",
+		Value:  "",
+		Desc:   `JSON object of the response profiles keyed by name, each with the fields, unrollContent, keepEmpty, dropEmptyArrays and format of the responses and the apiKeys selecting it, e.g. {"app": {"fields": ["id", "title", "bodyXML"], "unrollContent": true, "apiKeys": ["app-key"]}}`,
+		EnvVar: "RESPONSE_PROFILES",
+	})
+	profileHeader := app.String(cli.StringOpt{
+		Name:   "profile-header",
+		Value:  "X-Api-Key",
+		Desc:   "Request header whose value selects the response profile listing it in its apiKeys",
+		EnvVar: "PROFILE_HEADER",
+	})
+	fieldAccessPolicies := app.String(cli.StringOpt{
+		Name:   "access-policies",
+		Value:  "",
+		Desc:   `JSON object of the restricted fields, giving for each field path the access policies allowed to see it, e.g. {"topper": ["INTERNAL_UNSTABLE"], "publishReference": ["INTERNAL_UNSTABLE"]}`,
+		EnvVar: "ACCESS_POLICIES",
+	})
+	accessPolicyHeader := app.String(cli.StringOpt{
+		Name:   "access-policy-header",
+		Value:  "X-Policy",
+		Desc:   "Request header holding the comma separated access policies of the caller, set by the API gateway",
+		EnvVar: "ACCESS_POLICY_HEADER",
+	})
+	entitlementHeader := app.String(cli.StringOpt{
+		Name:   "entitlement-header",
+		Value:  "",
+		Desc:   "Trusted request header set by the API gateway to false when the caller is not entitled to the full content, which is then truncated. Disabled when empty",
+		EnvVar: "ENTITLEMENT_HEADER",
+	})
+	truncatedParagraphs := app.Int(cli.IntOpt{
+		Name:   "truncated-paragraphs",
+		Value:  2,
+		Desc:   "Number of paragraphs of the body returned to the callers which are not entitled to the content",
+		EnvVar: "TRUNCATED_PARAGRAPHS",
+	})
+	embargoPrivilegedPolicies := app.String(cli.StringOpt{
+		Name:   "embargo-privileged-policies",
+		Value:  "",
+		Desc:   "Comma separated access policies of the callers allowed to see the content under embargo with the embargo override header",
+		EnvVar: "EMBARGO_PRIVILEGED_POLICIES",
+	})
+	embargoOverrideHeader := app.String(cli.StringOpt{
+		Name:   "embargo-override-header",
+		Value:  "X-Embargo-Override",
+		Desc:   "Request header set to true by the privileged callers to see the content under embargo",
+		EnvVar: "EMBARGO_OVERRIDE_HEADER",
+	})
+	keepEmptyFields := app.String(cli.StringOpt{
+		Name:   "keep-empty-fields",
+		Value:  "",
+		Desc:   "Comma separated paths of the fields kept in the responses when they are null, empty strings or empty objects, e.g. byline,topper.headline",
+		EnvVar: "KEEP_EMPTY_FIELDS",
+	})
+	dropEmptyArrays := app.Bool(cli.BoolOpt{
+		Name:   "drop-empty-arrays",
+		Value:  false,
+		Desc:   "Whether to remove the empty arrays from the responses",
+		EnvVar: "DROP_EMPTY_ARRAYS",
+	})
+	maxPublishWaitMs := app.Int(cli.IntOpt{
+		Name:   "max-publish-wait-ms",
+		Value:  10000,
+		Desc:   "Maximum number of milliseconds a request can wait for the content to have the publishReference it expects",
+		EnvVar: "MAX_PUBLISH_WAIT_MS",
+	})
+	consistencyThreshold := app.String(cli.StringOpt{
+		Name:   "consistency-threshold",
+		Value:  "1m",
+		Desc:   "Maximum difference between the lastModified of the content and of its internal components from different publishes before they are reported as inconsistent",
+		EnvVar: "CONSISTENCY_THRESHOLD",
+	})
+	flagInconsistent := app.Bool(cli.BoolOpt{
+		Name:   "flag-inconsistent",
+		Value:  false,
+		Desc:   "Whether to flag the responses merged from inconsistent sources with the X-Content-Inconsistent header",
+		EnvVar: "FLAG_INCONSISTENT",
+	})
 	apiYml := app.String(cli.StringOpt{
 		Name:   "api-yml",
 		Value:  "./api.yml",
@@ -165,12 +297,45 @@ func main() {
 				}).DialContext,
 			},
 		}
+		rules, err := parseCacheControlRules(*cacheControlRules)
+		if err != nil {
+			logrus.Fatalf("Invalid cache control rules: %v", err)
+		}
+		fallbacks, err := parseFallbackSources(*contentFallbackSources)
+		if err != nil {
+			logrus.Fatalf("Invalid content fallback sources: %v", err)
+		}
+		filters, err := parseSourceFilters(*sourceFilters)
+		if err != nil {
+			logrus.Fatalf("Invalid source filters: %v", err)
+		}
+		profiles, err := parseResponseProfiles(*responseProfiles)
+		if err != nil {
+			logrus.Fatalf("Invalid response profiles: %v", err)
+		}
+		policies, err := parseAccessPolicies(*fieldAccessPolicies)
+		if err != nil {
+			logrus.Fatalf("Invalid access policies: %v", err)
+		}
+		if *truncatedParagraphs < 1 {
+			logrus.Fatalf("Invalid number of truncated paragraphs: %d", *truncatedParagraphs)
+		}
+		if *summaryLength < 1 {
+			logrus.Fatalf("Invalid summary length: %d", *summaryLength)
+		}
+		threshold, err := time.ParseDuration(*consistencyThreshold)
+		if err != nil {
+			logrus.Fatalf("Invalid consistency threshold: %v", err)
+		}
 		sc := serviceConfig{
-			appSystemCode:      *appSystemCode,
-			appName:            *appName,
-			appPort:            *appPort,
-			handlerPath:        *handlerPath,
-			cacheControlPolicy: *cacheControlPolicy,
+			appSystemCode:          *appSystemCode,
+			appName:                *appName,
+			appPort:                *appPort,
+			handlerPath:            *handlerPath,
+			cacheControlPolicy:     *cacheControlPolicy,
+			cacheControlRules:      rules,
+			surrogateControlPolicy: *surrogateControlPolicy,
+			goneCacheControlPolicy: *goneCacheControlPolicy,
 			content: externalService{
 				*contentSourceAppName,
 				*contentSourceURI,
@@ -178,6 +343,8 @@ func main() {
 				*contentSourceAppPanicGuide,
 				*contentSourceAppBusinessImpact,
 				1},
+			contentFallbacks: fallbacks,
+			sourceFilters:    filters,
 			internalComponents: externalService{
 				*internalComponentsSourceAppName,
 				*internalComponentsSourceURI,
@@ -192,15 +359,33 @@ func main() {
 				*contentUnrollerAppPanicGuide,
 				*contentUnrollerAppBusinessImpact,
 				2},
-			envAPIHost:         *envAPIHost,
-			httpClient:         httpClient,
+			identifierResolver: externalService{
+				appName: *identifierResolverAppName,
+				appURI:  *identifierResolverURI,
+			},
+			envAPIHost:                *envAPIHost,
+			httpClient:                httpClient,
+			readingMetadata:           *readingMetadata,
+			summaryLength:             *summaryLength,
+			emptyFieldPolicy:          emptyFieldPolicy{keep: parseFieldPaths(*keepEmptyFields), dropEmptyArrays: *dropEmptyArrays},
+			responseProfiles:          profiles,
+			profileHeader:             *profileHeader,
+			accessPolicies:            policies,
+			accessPolicyHeader:        *accessPolicyHeader,
+			entitlementHeader:         *entitlementHeader,
+			truncatedParagraphs:       *truncatedParagraphs,
+			embargoPrivilegedPolicies: parseFieldPaths(*embargoPrivilegedPolicies),
+			embargoOverrideHeader:     *embargoOverrideHeader,
+			consistencyThreshold:      threshold,
+			flagInconsistent:          *flagInconsistent,
+			maxPublishWait:            time.Duration(*maxPublishWaitMs) * time.Millisecond,
 		}
 		appLogger := newAppLogger()
 		metricsHandler := NewMetrics()
 		contentHandler := internalContentHandler{&sc, appLogger, &metricsHandler}
 		h := setupServiceHandler(sc, metricsHandler, contentHandler, apiYml)
 		appLogger.ServiceStartedEvent(*appSystemCode, sc.asMap())
-		err := http.ListenAndServe(":"+*appPort, h)
+		err = http.ListenAndServe(":"+*appPort, h)
 		if err != nil {
 			logrus.Fatalf("Unable to start server: %v", err)
 		}
@@ -210,8 +395,15 @@ func main() {
 
 func setupServiceHandler(sc serviceConfig, metricsHandler Metrics, contentHandler internalContentHandler, apiYml *string) *mux.Router {
 	r := mux.NewRouter()
+	r.Path("/" + sc.handlerPath).Handler(handlers.MethodHandler{"GET": oldhttphandlers.HTTPMetricsHandler(metricsHandler.registry,
+		oldhttphandlers.TransactionAwareRequestLoggingHandler(logrus.StandardLogger(), http.HandlerFunc(contentHandler.ServeByIdentifier)))})
 	r.Path("/" + sc.handlerPath + "/{uuid}").Handler(handlers.MethodHandler{"GET": oldhttphandlers.HTTPMetricsHandler(metricsHandler.registry,
 		oldhttphandlers.TransactionAwareRequestLoggingHandler(logrus.StandardLogger(), contentHandler))})
+	r.Path("/" + sc.handlerPath + "/{uuid}/references").Handler(handlers.MethodHandler{"GET": oldhttphandlers.HTTPMetricsHandler(metricsHandler.registry,
+		oldhttphandlers.TransactionAwareRequestLoggingHandler(logrus.StandardLogger(), http.HandlerFunc(contentHandler.ServeReferences)))})
+	r.Path("/" + sc.handlerPath + "/{uuid:.+}").Handler(handlers.MethodHandler{"GET": oldhttphandlers.HTTPMetricsHandler(metricsHandler.registry,
+		oldhttphandlers.TransactionAwareRequestLoggingHandler(logrus.StandardLogger(), contentHandler))})
+	r.Use(recoveryMiddleware(contentHandler.log, contentHandler.metrics))
 	r.Path(httphandlers.BuildInfoPath).HandlerFunc(httphandlers.BuildInfoHandler)
 	r.Path(httphandlers.PingPath).HandlerFunc(httphandlers.PingHandler)
 
@@ -252,16 +444,36 @@ type externalService struct {
 }
 
 type serviceConfig struct {
-	appSystemCode      string
-	appName            string
-	appPort            string
-	handlerPath        string
-	cacheControlPolicy string
-	content            externalService
-	internalComponents externalService
-	contentUnroller    externalService
-	envAPIHost         string
-	httpClient         *http.Client
+	appSystemCode             string
+	appName                   string
+	appPort                   string
+	handlerPath               string
+	cacheControlPolicy        string
+	cacheControlRules         []cacheControlRule
+	surrogateControlPolicy    string
+	goneCacheControlPolicy    string
+	content                   externalService
+	contentFallbacks          []fallbackSource
+	sourceFilters             map[string]sourceFilter
+	internalComponents        externalService
+	contentUnroller           externalService
+	identifierResolver        externalService
+	envAPIHost                string
+	httpClient                *http.Client
+	readingMetadata           bool
+	summaryLength             int
+	emptyFieldPolicy          emptyFieldPolicy
+	responseProfiles          map[string]responseProfile
+	profileHeader             string
+	accessPolicies            accessPolicies
+	accessPolicyHeader        string
+	entitlementHeader         string
+	truncatedParagraphs       int
+	embargoPrivilegedPolicies map[string]bool
+	embargoOverrideHeader     string
+	maxPublishWait            time.Duration
+	consistencyThreshold      time.Duration
+	flagInconsistent          bool
 }
 
 func (e externalService) asMap() map[string]interface{} {
@@ -275,15 +487,35 @@ func (e externalService) asMap() map[string]interface{} {
 
 func (sc serviceConfig) asMap() map[string]interface{} {
 	return map[string]interface{}{
-		"app-system-code":      sc.appSystemCode,
-		"app-name":             sc.appName,
-		"app-port":             sc.appPort,
-		"cache-control-policy": sc.cacheControlPolicy,
-		"handler-path":         sc.handlerPath,
-		"content-source":       sc.content.asMap(),
-		"internal-components":  sc.internalComponents.asMap(),
-		"content-unroller":     sc.contentUnroller.asMap(),
-		"env-api-host":         sc.envAPIHost,
-
+		"app-system-code":             sc.appSystemCode,
+		"app-name":                    sc.appName,
+		"app-port":                    sc.appPort,
+		"cache-control-policy":        sc.cacheControlPolicy,
+		"cache-control-rules":         sc.cacheControlRules,
+		"surrogate-control-policy":    sc.surrogateControlPolicy,
+		"gone-cache-control-policy":   sc.goneCacheControlPolicy,
+		"handler-path":                sc.handlerPath,
+		"content-source":              sc.content.asMap(),
+		"content-fallback-sources":    sc.contentFallbacks,
+		"source-filters":              sc.sourceFilters,
+		"internal-components":         sc.internalComponents.asMap(),
+		"content-unroller":            sc.contentUnroller.asMap(),
+		"identifier-resolver":         sc.identifierResolver.asMap(),
+		"env-api-host":                sc.envAPIHost,
+		"reading-metadata":            sc.readingMetadata,
+		"summary-length":              sc.summaryLength,
+		"keep-empty-fields":           sc.emptyFieldPolicy.keptPaths(),
+		"drop-empty-arrays":           sc.emptyFieldPolicy.dropEmptyArrays,
+		"response-profiles":           profileNames(sc.responseProfiles),
+		"profile-header":              sc.profileHeader,
+		"access-policies":             sc.accessPolicies,
+		"access-policy-header":        sc.accessPolicyHeader,
+		"entitlement-header":          sc.entitlementHeader,
+		"truncated-paragraphs":        sc.truncatedParagraphs,
+		"embargo-privileged-policies": sortedPolicies(sc.embargoPrivilegedPolicies),
+		"embargo-override-header":     sc.embargoOverrideHeader,
+		"max-publish-wait":            sc.maxPublishWait.String(),
+		"consistency-threshold":       sc.consistencyThreshold.String(),
+		"flag-inconsistent":           sc.flagInconsistent,
 	}
 }
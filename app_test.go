@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
 	"github.com/gorilla/handlers"
@@ -18,6 +20,8 @@ var internalContentAPI *httptest.Server
 var enrichedContentAPIMock *httptest.Server
 var contentPublicReadAPIMock *httptest.Server
 var contentUnrollerMock *httptest.Server
+var identifierResolverMock *httptest.Server
+var contentFallbackMock *httptest.Server
 
 func startEnrichedContentAPIMock(status string) {
 	router := mux.NewRouter()
@@ -33,6 +37,24 @@ func startEnrichedContentAPIMock(status string) {
 	} else if status == "notFound" {
 		getContent = notFoundHandler
 		health = happyHandler
+	} else if status == "gone" {
+		getContent = goneHandler
+		health = happyHandler
+	} else if status == "republished" {
+		getContent = republishedEnrichedContentAPIMock()
+		health = happyHandler
+	} else if status == "tombstone" {
+		getContent = tombstoneHandler
+		health = happyHandler
+	} else if status == "embargoed" {
+		getContent = embargoedHandler
+		health = happyHandler
+	} else if status == "embeds" {
+		getContent = embedsHandler
+		health = happyHandler
+	} else if status == "emptyByline" {
+		getContent = emptyBylineEnrichedContentAPIMock
+		health = happyHandler
 	} else {
 		getContent = internalErrorHandler
 		health = internalErrorHandler
@@ -62,6 +84,37 @@ func happyEnrichedContentAPIMock(writer http.ResponseWriter, request *http.Reque
 	io.Copy(writer, file)
 }
 
+// embedsHandler serves content with an embed in its second paragraph.
+func embedsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(`{"id": "http://www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "title": "Embeds", "accessLevel": "subscribed",
+		"bodyXML": "<body><p>First.</p><p>Second.</p><ft-content data-embedded=\"true\" type=\"http://www.ft.com/ontology/content/ImageSet\" url=\"http://api.ft.com/content/66101830-3863-11ea-bfdf-938130fb4080\"></ft-content></body>",
+		"embeds": [{"id": "http://api.ft.com/content/66101830-3863-11ea-bfdf-938130fb4080", "title": "Embedded image"}]}`))
+}
+
+// emptyBylineEnrichedContentAPIMock serves the content with an empty byline.
+func emptyBylineEnrichedContentAPIMock(writer http.ResponseWriter, request *http.Request) {
+	file, err := os.Open("test-resources/enriched-content-api-output.json")
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	content := getMapFromReader(file)
+	content["byline"] = ""
+	json.NewEncoder(writer).Encode(content)
+}
+
+// republishedEnrichedContentAPIMock serves the previous publish of the content twice before serving its new publish.
+func republishedEnrichedContentAPIMock() http.HandlerFunc {
+	var calls int32
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		happyEnrichedContentAPIMock(writer, request)
+	}
+}
+
 func internalErrorHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusInternalServerError)
 }
@@ -70,6 +123,20 @@ func notFoundHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotFound)
 }
 
+func goneHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusGone)
+}
+
+func tombstoneHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(`{"id": "http://www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "deleted": true}`))
+}
+
+// embargoedHandler serves content published in an hour.
+func embargoedHandler(w http.ResponseWriter, r *http.Request) {
+	publishedDate := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	w.Write([]byte(`{"id": "http://www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "title": "Embargoed", "publishedDate": "` + publishedDate + `"}`))
+}
+
 func badRequestHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusBadRequest)
 }
@@ -89,6 +156,9 @@ func startContentPublicReadAPIMock(status string) {
 	} else if status == "unrollContent" {
 		getContent = unrollContentPublicReadAPIMock
 		health = happyHandler
+	} else if status == "inconsistent" {
+		getContent = inconsistentContentPublicReadAPIMock
+		health = happyHandler
 	} else if status == "notFound" {
 		getContent = notFoundHandler
 		health = happyHandler
@@ -103,6 +173,10 @@ func startContentPublicReadAPIMock(status string) {
 	contentPublicReadAPIMock = httptest.NewServer(router)
 }
 
+func inconsistentContentPublicReadAPIMock(writer http.ResponseWriter, request *http.Request) {
+	writer.Write([]byte(`{"id": "http://www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "lastModified": "2017-02-27T13:00:00.000Z", "publishReference": "tid_previous"}`))
+}
+
 func startContentUnrollerServiceMock(status string) {
 	router := mux.NewRouter()
 	var getExpandedContent http.HandlerFunc
@@ -164,10 +238,41 @@ func unrollContentContentUnrollerMock(w http.ResponseWriter, r *http.Request) {
 	io.Copy(w, file)
 }
 
+func startIdentifierResolverMock() {
+	router := mux.NewRouter()
+	router.Path("/content-query").Handler(handlers.MethodHandler{"GET": http.HandlerFunc(contentQueryMock)})
+	identifierResolverMock = httptest.NewServer(router)
+}
+
+func contentQueryMock(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("webUrl") == "http://www.ft.com/cms/s/0/5c3cae78-dbef-11e6-9d7c-be108f1c1dce.html" ||
+		(q.Get("identifierAuthority") == "http://api.ft.com/system/FTCOM-METHODE" && q.Get("identifierValue") == "5c3cae78-dbef-11e6-9d7c-be108f1c1dce") {
+		w.Header().Set("Location", "http://api.ft.com/content/5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+		w.WriteHeader(http.StatusMovedPermanently)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func startContentFallbackMock() {
+	router := mux.NewRouter()
+	router.Path("/content/{uuid}").Handler(handlers.MethodHandler{"GET": http.HandlerFunc(happyEnrichedContentAPIMock)})
+	contentFallbackMock = httptest.NewServer(router)
+}
+
 func stopServices() {
 	internalContentAPI.Close()
 	enrichedContentAPIMock.Close()
 	contentPublicReadAPIMock.Close()
+	if identifierResolverMock != nil {
+		identifierResolverMock.Close()
+		identifierResolverMock = nil
+	}
+	if contentFallbackMock != nil {
+		contentFallbackMock.Close()
+		contentFallbackMock = nil
+	}
 }
 
 func startInternalContentService() {
@@ -177,35 +282,59 @@ func startInternalContentService() {
 	contentPublicReadAPIHealthURI := contentPublicReadAPIMock.URL + "/__health"
 	contentUnrollerURI := contentUnrollerMock.URL + "/internalcontent"
 	contentUnrollerHealthURI := contentUnrollerMock.URL + "/__health"
+	identifierResolverURI := ""
+	if identifierResolverMock != nil {
+		identifierResolverURI = identifierResolverMock.URL + "/content-query"
+	}
+	var contentFallbacks []fallbackSource
+	if contentFallbackMock != nil {
+		contentFallbacks = []fallbackSource{{AppName: "content-fallback", AppURI: contentFallbackMock.URL + "/content/"}}
+	}
 	sc := serviceConfig{
-		"internal-content-api",
-		"Internal Content API",
-		"8084",
-		"internalcontent",
-		"max-age=10",
-		externalService{
+		appSystemCode:          "internal-content-api",
+		appName:                "Internal Content API",
+		appPort:                "8084",
+		handlerPath:            "internalcontent",
+		cacheControlPolicy:     "max-age=10",
+		goneCacheControlPolicy: "max-age=30",
+		content: externalService{
 			"enriched-content-read-api",
 			enrichedContentAPIURI,
 			enrichedContentAPIHealthURI,
 			"panic guide",
 			"Source app business impact",
 			1},
-		externalService{
+		internalComponents: externalService{
 			"content-public-read",
 			contentPublicReadAPIURI,
 			contentPublicReadAPIHealthURI,
 			"panic guide",
 			"Internal components app business impact",
 			2},
-		externalService{
+		contentUnroller: externalService{
 			"content-unroller",
 			contentUnrollerURI,
 			contentUnrollerHealthURI,
 			"panic guide",
 			"Image resolver app business imapct",
 			2},
-		"api.ft.com",
-		http.DefaultClient,
+		contentFallbacks: contentFallbacks,
+		identifierResolver: externalService{
+			appName: "document-store-api",
+			appURI:  identifierResolverURI,
+		},
+		envAPIHost:                "api.ft.com",
+		httpClient:                http.DefaultClient,
+		summaryLength:             200,
+		maxPublishWait:            time.Second,
+		consistencyThreshold:      time.Minute,
+		flagInconsistent:          true,
+		responseProfiles:          testResponseProfiles(),
+		accessPolicyHeader:        "X-Policy",
+		embargoPrivilegedPolicies: map[string]bool{"INTERNAL_UNSTABLE": true},
+		embargoOverrideHeader:     "X-Embargo-Override",
+		entitlementHeader:         "X-Entitled",
+		truncatedParagraphs:       1,
 	}
 
 	appLogger := newAppLogger()
@@ -263,6 +392,138 @@ func TestShouldReturn200AndInternalComponentOutput(t *testing.T) {
 	assert.Equal(t, nil, e, "Error %v", e)
 	assert.Equal(t, true, areEqual, "Error %v", areEqual)
 	assert.Equal(t, "max-age=10", resp.Header.Get("Cache-Control"), "Should have cache control set")
+	assert.Equal(t, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce f3add2e0-dbfa-11e6-a7d5-ce30ecef69c7 35059e34-dc33-11e6-86ac-f253db7791c6 c374c260-dd84-11e6-9d7c-be108f1c1dce a5dcd3e2-3645-3f79-a4f5-90c3a4679326",
+		resp.Header.Get("Surrogate-Key"), "Should have surrogate keys set")
+	assert.Equal(t, "enriched-content-read-api", resp.Header.Get("X-Content-Source"), "Should record the content source")
+	assert.Empty(t, resp.Header.Get("X-Content-Inconsistent"), "Should not flag a consistent response")
+}
+
+func TestShouldApplyTheEmptyFieldPolicyOfTheRequest(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce?keepEmpty=alternativeTitles&dropEmptyArrays=true")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status should be 200")
+	actualOutput := getMapFromReader(resp.Body)
+	assert.Equal(t, map[string]interface{}{}, actualOutput["alternativeTitles"], "Should keep the requested empty field")
+	assert.NotContains(t, actualOutput, "alternativeStandfirsts", "Should remove the other empty fields")
+	assert.NotContains(t, actualOutput, "containedIn", "Should remove the empty arrays")
+	assert.NotContains(t, actualOutput, "curatedRelatedContent", "Should remove the empty arrays")
+}
+
+func TestShouldKeepAnEmptyTypedFieldRequestedByKeepEmpty(t *testing.T) {
+	startEnrichedContentAPIMock("emptyByline")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce?keepEmpty=byline")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status should be 200")
+	actualOutput := getMapFromReader(resp.Body)
+	assert.Contains(t, actualOutput, "byline", "Should keep the requested empty byline")
+	assert.Equal(t, "", actualOutput["byline"], "Should keep the requested empty byline")
+
+	resp, err = http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.NotContains(t, getMapFromReader(resp.Body), "byline", "Should remove the empty byline by default")
+}
+
+func TestShouldReturn400ForAnInvalidDropEmptyArraysParameter(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce?dropEmptyArrays=sometimes")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Response status should be 400")
+	assert.Equal(t, "application/problem+json; charset=utf-8", resp.Header.Get("Content-Type"))
+}
+
+func testResponseProfiles() map[string]responseProfile {
+	profiles, err := parseResponseProfiles(`{
+		"search": {"fields": ["id", "title", "types", "topper.headline"], "dropEmptyArrays": true},
+		"syndication": {"format": "text/plain"}
+	}`)
+	if err != nil {
+		panic(err)
+	}
+	return profiles
+}
+
+func TestShouldApplyTheRequestedProfile(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce?profile=search")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status should be 200")
+	actualOutput := getMapFromReader(resp.Body)
+	assert.ElementsMatch(t, []string{"id", "title", "types", "topper"}, keysOf(actualOutput), "Should only return the fields of the profile")
+	assert.ElementsMatch(t, []string{"headline"}, keysOf(actualOutput["topper"].(map[string]interface{})), "Should only return the nested fields of the profile")
+	assert.Contains(t, resp.Header.Get("Surrogate-Key"), "a5dcd3e2-3645-3f79-a4f5-90c3a4679326", "Should compute the surrogate keys from the whole content")
+
+	resp, err = http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce?profile=syndication")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status should be 200")
+	assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"), "Should render the format of the profile")
+}
+
+func TestShouldReturn400ForAnUnknownProfile(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce?profile=unknown")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Response status should be 400")
+}
+
+func keysOf(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
 }
 
 func TestShouldReturn200WhenUnrollContentIsTrueAndInternalComponentOutput(t *testing.T) {
@@ -300,6 +561,228 @@ func TestShouldReturn200WhenUnrollContentIsTrueAndInternalComponentOutput(t *tes
 	assert.Equal(t, "max-age=10", resp.Header.Get("Cache-Control"), "Should have cache control set")
 }
 
+func TestShouldReturn200AndHTMLPreviewWhenAcceptIsHTML(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	req, err := http.NewRequest(http.MethodGet, internalContentAPI.URL+"/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", nil)
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status should be 200")
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "Accept, X-Policy, X-Entitled", resp.Header.Get("Vary"))
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), "<h1>Topper headline</h1>")
+}
+
+func TestShouldReturn200AndNITFWhenAcceptIsNITF(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	req, err := http.NewRequest(http.MethodGet, internalContentAPI.URL+"/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", nil)
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	req.Header.Set("Accept", "application/nitf+xml")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status should be 200")
+	assert.Equal(t, "application/nitf+xml; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), `<doc-id id-string="5c3cae78-dbef-11e6-9d7c-be108f1c1dce"></doc-id>`)
+}
+
+func TestShouldReturn200AndReferences(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce/references")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to references endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status should be 200")
+
+	var refs contentReferences
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&refs))
+	assert.Equal(t, "Accept, X-Policy, X-Entitled", resp.Header.Get("Vary"))
+	assert.Equal(t, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", refs.ID)
+	assert.Contains(t, refs.References, reference{"c374c260-dd84-11e6-9d7c-be108f1c1dce", "leadImage"})
+	assert.Contains(t, refs.References, reference{"a5dcd3e2-3645-3f79-a4f5-90c3a4679326", "annotation"})
+}
+
+func TestShouldHideTheReferencesWithheldFromTheCaller(t *testing.T) {
+	startEnrichedContentAPIMock("embeds")
+	startContentPublicReadAPIMock("notFound")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	for _, path := range []string{"/references", ""} {
+		req, err := http.NewRequest(http.MethodGet, internalContentAPI.URL+"/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce"+path, nil)
+		if err != nil {
+			assert.FailNow(t, "Cannot create request to internalcontent endpoint", err.Error())
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+		}
+		resp.Body.Close()
+		assert.Contains(t, resp.Header.Get("Surrogate-Key"), "66101830-3863-11ea-bfdf-938130fb4080", "Should tag the embed of the full content%s", path)
+
+		req.Header.Set("X-Entitled", "false")
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+		}
+		body := getMapFromReader(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Equal(t, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", resp.Header.Get("Surrogate-Key"), "Should not reveal the withheld embed%s", path)
+		if path != "" {
+			assert.Empty(t, body["references"], "Should not list the withheld embed")
+		}
+	}
+}
+
+func TestShouldReturn404ForReferencesOfMissingContent(t *testing.T) {
+	startEnrichedContentAPIMock("notFound")
+	startContentPublicReadAPIMock("notFound")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce/references")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to references endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Response status should be 404")
+}
+
+func TestShouldReturn200WhenLookingUpByIdentifier(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startIdentifierResolverMock()
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent?identifierAuthority=http://api.ft.com/system/FTCOM-METHODE&identifierValue=5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status should be 200")
+
+	file, _ := os.Open("test-resources/full-internal-content-api-output.json")
+	defer file.Close()
+
+	expectedOutput := getMapFromReader(file)
+	actualOutput := getMapFromReader(resp.Body)
+	assert.Equal(t, expectedOutput, actualOutput, "Response body should be the content of the resolved uuid")
+}
+
+func TestShouldRedirectToCanonicalURLWhenLookingUpByWebURL(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startIdentifierResolverMock()
+	startInternalContentService()
+	defer stopServices()
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(internalContentAPI.URL + "/internalcontent?webUrl=http://www.ft.com/cms/s/0/5c3cae78-dbef-11e6-9d7c-be108f1c1dce.html&redirect=true&unrollContent=true")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode, "Response status should be 302")
+	assert.Equal(t, "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce?unrollContent=true", resp.Header.Get("Location"))
+}
+
+func TestShouldReturn404WhenIdentifierIsNotResolved(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startIdentifierResolverMock()
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent?webUrl=http://www.ft.com/unknown")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Response status should be 404")
+}
+
+func TestShouldReturn400WhenLookingUpWithoutIdentifier(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startIdentifierResolverMock()
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent?identifierAuthority=http://api.ft.com/system/FTCOM-METHODE")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Response status should be 400")
+}
+
+func TestShouldReturn501WhenIdentifierResolverIsNotConfigured(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent?webUrl=http://www.ft.com/cms/s/0/5c3cae78-dbef-11e6-9d7c-be108f1c1dce.html")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotImplemented, resp.StatusCode, "Response status should be 501")
+}
+
 func TestShouldReturn200AndInternalComponentOutputWhenUnrollContentReturns400(t *testing.T) {
 	startEnrichedContentAPIMock("happy")
 	startContentPublicReadAPIMock("happy")
@@ -380,6 +863,225 @@ func TestShouldReturn404(t *testing.T) {
 	defer resp.Body.Close()
 
 	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Response status should be 404")
+	assert.Equal(t, "application/problem+json; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	var problem Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, problemTypeBaseURI+"content-not-found", problem.Type)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "enriched-content-read-api", problem.Upstream)
+	assert.Equal(t, http.StatusNotFound, problem.UpstreamStatus)
+}
+
+func TestShouldReturn200FromFallbackSourceWhenContentSourceDoesNotProvideTheContent(t *testing.T) {
+	for _, status := range []string{"notFound", "unhappy"} {
+		startEnrichedContentAPIMock(status)
+		startContentPublicReadAPIMock("happy")
+		startContentUnrollerServiceMock("happy")
+		startContentFallbackMock()
+		startInternalContentService()
+
+		resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+		if err != nil {
+			stopServices()
+			assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+		}
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status should be 200 when content source is %s", status)
+		assert.Equal(t, "content-fallback", resp.Header.Get("X-Content-Source"), status)
+
+		file, _ := os.Open("test-resources/full-internal-content-api-output.json")
+		expectedOutput := getMapFromReader(file)
+		actualOutput := getMapFromReader(resp.Body)
+		areEqual, e := compareResults(expectedOutput, actualOutput)
+		assert.NoError(t, e, status)
+		assert.True(t, areEqual, "Internal content should be served from the fallback source when content source is %s", status)
+
+		file.Close()
+		resp.Body.Close()
+		stopServices()
+	}
+}
+
+func TestShouldNotFallBackWhenContentIsDeleted(t *testing.T) {
+	startEnrichedContentAPIMock("gone")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startContentFallbackMock()
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusGone, resp.StatusCode, "Response status should be 410")
+}
+
+func TestShouldReturn200WhenContentHasTheExpectedPublishReference(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce?publishReference=tid_9h0oph0oil")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status should be 200")
+	assert.Equal(t, "tid_9h0oph0oil", getMapFromReader(resp.Body)["publishReference"])
+}
+
+func TestShouldWaitForTheExpectedPublishReference(t *testing.T) {
+	startEnrichedContentAPIMock("republished")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce?publishReference=tid_9h0oph0oil&waitMs=1000")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status should be 200")
+	assert.Equal(t, "tid_9h0oph0oil", getMapFromReader(resp.Body)["publishReference"])
+}
+
+func TestShouldReturnStaleContentWhenPublishReferenceDoesNotMatch(t *testing.T) {
+	data := []struct {
+		query          string
+		expectedStatus int
+	}{
+		{"?publishReference=tid_newer", http.StatusConflict},
+		{"?publishReference=tid_newer&waitMs=300", http.StatusGatewayTimeout},
+	}
+
+	for _, row := range data {
+		startEnrichedContentAPIMock("happy")
+		startContentPublicReadAPIMock("happy")
+		startContentUnrollerServiceMock("happy")
+		startInternalContentService()
+
+		resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce" + row.query)
+		if err != nil {
+			stopServices()
+			assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+		}
+
+		assert.Equal(t, row.expectedStatus, resp.StatusCode, row.query)
+		assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"), row.query)
+		assert.Empty(t, resp.Header.Get("Surrogate-Control"), row.query)
+		assert.Equal(t, "tid_9h0oph0oil", getMapFromReader(resp.Body)["publishReference"], row.query)
+
+		resp.Body.Close()
+		stopServices()
+	}
+}
+
+func TestShouldReturn400WhenWaitIsInvalid(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce?publishReference=tid_newer&waitMs=soon")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Response status should be 400")
+}
+
+func TestShouldFlagResponseWhenSourcesAreInconsistent(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("inconsistent")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status should be 200")
+	assert.Equal(t, "true", resp.Header.Get("X-Content-Inconsistent"), "Should flag the inconsistent response")
+	assert.Equal(t, "tid_9h0oph0oil", getMapFromReader(resp.Body)["publishReference"], "Should keep the version of the content source")
+}
+
+func TestShouldGuardContentUnderEmbargo(t *testing.T) {
+	startEnrichedContentAPIMock("embargoed")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	testCases := []struct {
+		name         string
+		headers      map[string]string
+		status       int
+		cacheControl string
+	}{
+		{"public caller", map[string]string{"X-Embargo-Override": "true"}, http.StatusNotFound, "max-age=10"},
+		{"privileged caller", map[string]string{"X-Policy": "INTERNAL_UNSTABLE"}, http.StatusForbidden, "max-age=10"},
+		{"privileged caller overriding the embargo", map[string]string{"X-Policy": "INTERNAL_UNSTABLE", "X-Embargo-Override": "true"}, http.StatusOK, "private, no-store"},
+	}
+	for _, tc := range testCases {
+		req, err := http.NewRequest(http.MethodGet, internalContentAPI.URL+"/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", nil)
+		if err != nil {
+			assert.FailNow(t, "Cannot create request to internalcontent endpoint", err.Error())
+		}
+		for name, value := range tc.headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+		}
+		resp.Body.Close()
+
+		assert.Equal(t, tc.status, resp.StatusCode, "Unexpected status for a %s", tc.name)
+		assert.Equal(t, tc.cacheControl, resp.Header.Get("Cache-Control"), "Unexpected Cache-Control for a %s", tc.name)
+		assert.Contains(t, resp.Header.Get("Surrogate-Key"), "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "Unexpected Surrogate-Key for a %s", tc.name)
+		assert.Contains(t, resp.Header.Get("Vary"), "X-Policy", "Unexpected Vary for a %s", tc.name)
+	}
+}
+
+func TestShouldReturn410WhenContentIsDeleted(t *testing.T) {
+	for _, status := range []string{"gone", "tombstone"} {
+		startEnrichedContentAPIMock(status)
+		startContentPublicReadAPIMock("happy")
+		startContentUnrollerServiceMock("happy")
+		startInternalContentService()
+
+		resp, err := http.Get(internalContentAPI.URL + "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+		if err != nil {
+			stopServices()
+			assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+		}
+
+		assert.Equal(t, http.StatusGone, resp.StatusCode, "Response status should be 410 when upstream is %s", status)
+		assert.Equal(t, "max-age=30", resp.Header.Get("Cache-Control"), status)
+		assert.Equal(t, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", resp.Header.Get("Surrogate-Key"), status)
+
+		var problem Problem
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem), status)
+		assert.Equal(t, problemTypeBaseURI+"content-gone", problem.Type, status)
+		assert.Equal(t, "enriched-content-read-api", problem.Upstream, status)
+
+		resp.Body.Close()
+		stopServices()
+	}
 }
 
 func TestShouldReturn200AndPartialInternalComponentOutputWhenDocumentNotFound(t *testing.T) {
@@ -443,6 +1145,12 @@ func TestShouldReturn503whenEnrichedContentApiIsNotAvailable(t *testing.T) {
 	defer resp.Body.Close()
 
 	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "Response status should be 503")
+
+	var problem Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, problemTypeBaseURI+"upstream-unavailable", problem.Type)
+	assert.Equal(t, "enriched-content-read-api", problem.Upstream)
+	assert.NotZero(t, problem.UpstreamStatus)
 }
 
 func TestShouldBeHealthy(t *testing.T) {
@@ -650,50 +1358,172 @@ func TestShouldReturn400WhenInvalidUUID(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Response status should be 400")
 }
 
+func TestShouldRedirectToCanonicalURLWhenUUIDIsAVariant(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	for _, variant := range []string{
+		"5C3CAE78-DBEF-11E6-9D7C-BE108F1C1DCE",
+		"%7B5c3cae78-dbef-11e6-9d7c-be108f1c1dce%7D",
+		"urn:uuid:5c3cae78-dbef-11e6-9d7c-be108f1c1dce",
+	} {
+		resp, err := client.Get(internalContentAPI.URL + "/internalcontent/" + variant + "?unrollContent=true")
+		if err != nil {
+			assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+		}
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode, "Response status should be 301 for %s", variant)
+		assert.Equal(t, "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce?unrollContent=true", resp.Header.Get("Location"), variant)
+	}
+}
+
+func TestShouldReturn200AfterRedirectsWhenUUIDIsAThingURL(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/http://www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Response status should be 200")
+	assert.Equal(t, "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", resp.Request.URL.Path)
+}
+
+func TestShouldReturn400WhenThePathOnlyEndsWithAUUID(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	for _, path := range []string{
+		"foo/bar/5c3cae78-dbef-11e6-9d7c-be108f1c1dce",
+		"5c3cae78-dbef-11e6-9d7c-be108f1c1dce/f3add2e0-dbfa-11e6-a7d5-ce30ecef69c7",
+	} {
+		resp, err := client.Get(internalContentAPI.URL + "/internalcontent/" + path)
+		if err != nil {
+			assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+		}
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Response status should be 400 for %s", path)
+		assert.Empty(t, resp.Header.Get("Location"), path)
+	}
+}
+
+func TestShouldReturnStructuredErrorWhenInvalidUUID(t *testing.T) {
+	startEnrichedContentAPIMock("happy")
+	startContentPublicReadAPIMock("happy")
+	startContentUnrollerServiceMock("happy")
+	startInternalContentService()
+	defer stopServices()
+
+	resp, err := http.Get(internalContentAPI.URL + "/internalcontent/http://www.ft.com/thing/123-invalid-uuid")
+	if err != nil {
+		assert.FailNow(t, "Cannot send request to internalcontent endpoint", err.Error())
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Response status should be 400")
+
+	assert.Equal(t, "application/problem+json; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	var problem Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, problemTypeBaseURI+"invalid-uuid", problem.Type)
+	assert.Equal(t, "Invalid content uuid", problem.Title)
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, "http:/www.ft.com/thing/123-invalid-uuid", problem.UUID)
+	assert.NotEmpty(t, problem.Detail)
+	assert.NotEmpty(t, problem.TransactionID)
+}
+
 func TestServiceAsMap(t *testing.T) {
 	sc := serviceConfig{
-		"appSystemCode",
-		"appName",
-		"appPort",
-		"handlerPath",
-		"cacheControlPolicy",
-		externalService{
+		appSystemCode:          "appSystemCode",
+		appName:                "appName",
+		appPort:                "appPort",
+		handlerPath:            "handlerPath",
+		cacheControlPolicy:     "cacheControlPolicy",
+		cacheControlRules:      []cacheControlRule{{CacheControl: "cacheControlRule"}},
+		surrogateControlPolicy: "surrogateControlPolicy",
+		goneCacheControlPolicy: "goneCacheControlPolicy",
+		content: externalService{
 			"contentSourceAppName",
 			"contentSourceURI",
 			"contentSourceAppHealthURI",
 			"contentSourceAppPanicGuide",
 			"contentSourceAppBusinessImpact",
 			1},
-		externalService{
+		internalComponents: externalService{
 			"internalComponentsSourceAppName",
 			"internalComponentsSourceURI",
 			"internalComponentsSourceAppHealthURI",
 			"internalComponentsSourceAppPanicGuide",
 			"internalComponentsSourceAppBusinessImpact",
 			2},
-		externalService{
+		contentUnroller: externalService{
 			"contentUnrollerAppName",
 			"contentUnrollerSourceURI",
 			"contentUnrollerAppHealthURI",
 			"contentUnrollerAppPanicGuide",
 			"contentUnrollerAppBusinessImpact",
 			2},
-		"envAPIHost",
-		nil,
+		contentFallbacks:          []fallbackSource{{AppName: "fallbackAppName", AppURI: "fallbackURI"}},
+		sourceFilters:             map[string]sourceFilter{"internalComponentsSourceAppName": {Fields: []string{"id"}, Embeds: []string{}}},
+		responseProfiles:          map[string]responseProfile{"web": {}, "app": {APIKeys: []string{"app-key"}}},
+		profileHeader:             "X-Api-Key",
+		accessPolicies:            accessPolicies{"topper": {"INTERNAL_UNSTABLE"}},
+		accessPolicyHeader:        "X-Policy",
+		entitlementHeader:         "X-Entitled",
+		truncatedParagraphs:       3,
+		embargoPrivilegedPolicies: map[string]bool{"INTERNAL_UNSTABLE": true},
+		embargoOverrideHeader:     "X-Embargo-Override",
+		identifierResolver: externalService{
+			appName: "identifierResolverAppName",
+			appURI:  "identifierResolverURI",
+		},
+		envAPIHost:           "envAPIHost",
+		readingMetadata:      true,
+		summaryLength:        150,
+		emptyFieldPolicy:     emptyFieldPolicy{keep: map[string]bool{"standfirst": true, "byline": true}, dropEmptyArrays: true},
+		maxPublishWait:       5 * time.Second,
+		consistencyThreshold: time.Minute,
+		flagInconsistent:     true,
 	}
 	resp := sc.asMap()
 	expected := map[string]interface{}{
-		"app-system-code":      "appSystemCode",
-		"app-name":             "appName",
-		"app-port":             "appPort",
-		"cache-control-policy": "cacheControlPolicy",
-		"handler-path":         "handlerPath",
+		"app-system-code":           "appSystemCode",
+		"app-name":                  "appName",
+		"app-port":                  "appPort",
+		"cache-control-policy":      "cacheControlPolicy",
+		"cache-control-rules":       []cacheControlRule{{CacheControl: "cacheControlRule"}},
+		"surrogate-control-policy":  "surrogateControlPolicy",
+		"gone-cache-control-policy": "goneCacheControlPolicy",
+		"handler-path":              "handlerPath",
 		"content-source": map[string]interface{}{
 			"app-uri":             "contentSourceURI",
 			"app-name":            "contentSourceAppName",
 			"app-health-uri":      "contentSourceAppHealthURI",
 			"app-panic-guide":     "contentSourceAppPanicGuide",
 			"app-business-impact": "contentSourceAppBusinessImpact"},
+		"content-fallback-sources": []fallbackSource{{AppName: "fallbackAppName", AppURI: "fallbackURI"}},
+		"source-filters":           map[string]sourceFilter{"internalComponentsSourceAppName": {Fields: []string{"id"}, Embeds: []string{}}},
 		"internal-components": map[string]interface{}{
 			"app-uri":             "internalComponentsSourceURI",
 			"app-name":            "internalComponentsSourceAppName",
@@ -706,7 +1536,28 @@ func TestServiceAsMap(t *testing.T) {
 			"app-health-uri":      "contentUnrollerAppHealthURI",
 			"app-panic-guide":     "contentUnrollerAppPanicGuide",
 			"app-business-impact": "contentUnrollerAppBusinessImpact"},
-		"env-api-host": "envAPIHost",
+		"identifier-resolver": map[string]interface{}{
+			"app-uri":             "identifierResolverURI",
+			"app-name":            "identifierResolverAppName",
+			"app-health-uri":      "",
+			"app-panic-guide":     "",
+			"app-business-impact": ""},
+		"env-api-host":                "envAPIHost",
+		"reading-metadata":            true,
+		"summary-length":              150,
+		"keep-empty-fields":           []string{"byline", "standfirst"},
+		"drop-empty-arrays":           true,
+		"response-profiles":           []string{"app", "web"},
+		"profile-header":              "X-Api-Key",
+		"access-policies":             accessPolicies{"topper": {"INTERNAL_UNSTABLE"}},
+		"access-policy-header":        "X-Policy",
+		"entitlement-header":          "X-Entitled",
+		"truncated-paragraphs":        3,
+		"embargo-privileged-policies": []string{"INTERNAL_UNSTABLE"},
+		"embargo-override-header":     "X-Embargo-Override",
+		"max-publish-wait":            "5s",
+		"consistency-threshold":       "1m0s",
+		"flag-inconsistent":           true,
 	}
 	assert.Equal(t, resp, expected, "Wrong return from asMap")
 }
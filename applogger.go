@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	tid "github.com/Financial-Times/transactionid-utils-go"
 	"github.com/sirupsen/logrus"
@@ -81,6 +83,75 @@ func (appLogger *appLogger) RequestFailedEvent(serviceName string, requestURL st
 		Debugf("Request failed. %s responded with %s", serviceName, resp.Status)
 }
 
+func (appLogger *appLogger) FallbackEvent(serviceName string, fallbackServiceName string, transactionID string, uuid string) {
+	appLogger.log.WithFields(logrus.Fields{
+		"event":          "fallback",
+		"transaction_id": transactionID,
+		"uuid":           uuid,
+	}).
+		Infof("Content not provided by %s, falling back to %s", serviceName, fallbackServiceName)
+}
+
+func (appLogger *appLogger) StalePublishReferenceEvent(expected string, actual interface{}, transactionID string, uuid string) {
+	appLogger.log.WithFields(logrus.Fields{
+		"event":                      "stale_publish_reference",
+		"expected_publish_reference": expected,
+		"publish_reference":          actual,
+		"transaction_id":             transactionID,
+		"uuid":                       uuid,
+	}).
+		Info("Content was not updated to the expected publish reference in time")
+}
+
+func (appLogger *appLogger) InconsistentSourcesEvent(serviceName string, version sourceVersion, otherServiceName string, otherVersion sourceVersion, drift time.Duration, transactionID string, uuid string) {
+	appLogger.log.WithFields(logrus.Fields{
+		"event":                   "inconsistent_sources",
+		"last_modified":           version.lastModified,
+		"publish_reference":       version.publishReference,
+		"other_last_modified":     otherVersion.lastModified,
+		"other_publish_reference": otherVersion.publishReference,
+		"last_modified_drift":     drift.String(),
+		"transaction_id":          transactionID,
+		"uuid":                    uuid,
+	}).
+		Warnf("Content of %s and %s disagree", serviceName, otherServiceName)
+}
+
+func (appLogger *appLogger) AccessDeniedEvent(requestURL string, transactionID string, uuid string, policies []string, fields []string) {
+	appLogger.log.WithFields(logrus.Fields{
+		"event":          "access_denied",
+		"request_url":    requestURL,
+		"transaction_id": transactionID,
+		"uuid":           uuid,
+		"policies":       policies,
+		"denied_fields":  fields,
+	}).
+		Info("Removed the fields the access policies of the request are not allowed to see")
+}
+
+func (appLogger *appLogger) EmbargoEvent(requestURL string, transactionID string, uuid string, embargo time.Time, overridden bool) {
+	appLogger.log.WithFields(logrus.Fields{
+		"event":          "embargo",
+		"request_url":    requestURL,
+		"transaction_id": transactionID,
+		"uuid":           uuid,
+		"embargo":        embargo.UTC().Format(time.RFC3339),
+		"overridden":     overridden,
+	}).
+		Info("Content is under embargo")
+}
+
+func (appLogger *appLogger) PanicEvent(requestURL string, transactionID string, recovered interface{}, stack []byte) {
+	appLogger.log.WithFields(logrus.Fields{
+		"event":          "panic",
+		"request_url":    requestURL,
+		"transaction_id": transactionID,
+		"error":          fmt.Sprint(recovered),
+		"stack":          string(stack),
+	}).
+		Error("Recovered from panic")
+}
+
 func (appLogger *appLogger) ResponseEvent(serviceName string, requestURL string, resp *http.Response, uuid string) {
 	appLogger.log.WithFields(logrus.Fields{
 		"event":          "response",
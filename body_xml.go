@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+var blockElements = map[string]bool{
+	"p":          true,
+	"h1":         true,
+	"h2":         true,
+	"h3":         true,
+	"h4":         true,
+	"h5":         true,
+	"h6":         true,
+	"li":         true,
+	"blockquote": true,
+	"pull-quote": true,
+	"ft-content": true,
+	"body":       true,
+}
+
+func newBodyXMLDecoder(bodyXML string) *xml.Decoder {
+	decoder := xml.NewDecoder(strings.NewReader(bodyXML))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	return decoder
+}
+
+type bodyBlock struct {
+	element string
+	text    string
+}
+
+// bodyBlocks returns the block elements of the given bodyXML with their whitespace collapsed text, skipping the empty ones.
+func bodyBlocks(bodyXML string) []bodyBlock {
+	var blocks []bodyBlock
+	var current strings.Builder
+	var open []string
+	flush := func() {
+		if text := collapseWhitespace(current.String()); text != "" {
+			element := "p"
+			if len(open) > 0 && open[len(open)-1] != "body" {
+				element = open[len(open)-1]
+			}
+			blocks = append(blocks, bodyBlock{element, text})
+		}
+		current.Reset()
+	}
+
+	decoder := newBodyXMLDecoder(bodyXML)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if blockElements[t.Name.Local] {
+				flush()
+				open = append(open, t.Name.Local)
+			}
+		case xml.EndElement:
+			if blockElements[t.Name.Local] {
+				flush()
+				if len(open) > 0 {
+					open = open[:len(open)-1]
+				}
+			}
+		case xml.CharData:
+			current.Write(t)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// bodyParagraphs returns the text of the block elements of the given bodyXML, one entry per block.
+func bodyParagraphs(bodyXML string) []string {
+	var paragraphs []string
+	for _, block := range bodyBlocks(bodyXML) {
+		paragraphs = append(paragraphs, block.text)
+	}
+	return paragraphs
+}
+
+// bodyText returns the plain text of the given bodyXML, with the blocks separated by a single space.
+func bodyText(bodyXML string) string {
+	return strings.Join(bodyParagraphs(bodyXML), " ")
+}
+
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+type bodyNode struct {
+	name     string
+	attrs    map[string]string
+	text     string
+	children []*bodyNode
+}
+
+// parseBodyTree parses the given bodyXML into a tree of elements and text nodes, tolerating HTML-like markup.
+// Text nodes have an empty name.
+func parseBodyTree(bodyXML string) *bodyNode {
+	root := &bodyNode{}
+	stack := []*bodyNode{root}
+	decoder := newBodyXMLDecoder(bodyXML)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &bodyNode{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, attr := range t.Attr {
+				node.attrs[attr.Name.Local] = attr.Value
+			}
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.children = append(parent.children, &bodyNode{text: string(t)})
+		}
+	}
+	return root
+}
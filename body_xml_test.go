@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyParagraphs(t *testing.T) {
+	data := []struct {
+		name       string
+		bodyXML    string
+		paragraphs []string
+	}{
+		{"empty body", "", nil},
+		{"paragraphs", "<body><p>One</p>\n<p>Two <a href=\"http://ft.com\">link</a></p></body>", []string{"One", "Two link"}},
+		{"headings and lists", "<body><h2>Title</h2><ul><li>a</li><li>b</li></ul></body>", []string{"Title", "a", "b"}},
+		{"entities", "<body><p>A &amp; B&nbsp;C</p></body>", []string{"A & B C"}},
+		{"embeds are skipped", "<body><ft-content url=\"http://api.ft.com/content/1\"></ft-content><p>text</p></body>", []string{"text"}},
+	}
+
+	for _, row := range data {
+		assert.Equal(t, row.paragraphs, bodyParagraphs(row.bodyXML), row.name)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type ruleDuration time.Duration
+
+func (d *ruleDuration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = ruleDuration(parsed)
+	return nil
+}
+
+func (d ruleDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// cacheControlRule selects its Cache-Control policy when all of its conditions are met.
+// Conditions which are not set always match.
+type cacheControlRule struct {
+	Types        []string      `json:"types,omitempty"`
+	MinAge       *ruleDuration `json:"minAge,omitempty"`
+	MaxAge       *ruleDuration `json:"maxAge,omitempty"`
+	Partial      *bool         `json:"partial,omitempty"`
+	CacheControl string        `json:"cacheControl"`
+}
+
+func parseCacheControlRules(rules string) ([]cacheControlRule, error) {
+	var parsed []cacheControlRule
+	if rules == "" {
+		return parsed, nil
+	}
+	// an unknown condition is rejected rather than ignored, as the rule would otherwise match every response
+	decoder := json.NewDecoder(strings.NewReader(rules))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&parsed)
+	return parsed, err
+}
+
+func (rule cacheControlRule) matches(content map[string]interface{}, age time.Duration, hasAge bool, state *responseState) bool {
+	if len(rule.Types) > 0 && !hasAnyType(content, rule.Types) {
+		return false
+	}
+	if rule.MinAge != nil && (!hasAge || age < time.Duration(*rule.MinAge)) {
+		return false
+	}
+	if rule.MaxAge != nil && (!hasAge || age > time.Duration(*rule.MaxAge)) {
+		return false
+	}
+	if rule.Partial != nil && *rule.Partial != state.isPartial() {
+		return false
+	}
+	return true
+}
+
+// selectCacheControl returns the policy of the first rule matching the content and the state of the response,
+// or the default policy when none of them matches.
+func selectCacheControl(rules []cacheControlRule, defaultPolicy string, content map[string]interface{}, state *responseState, now time.Time) string {
+	age, hasAge := contentAge(content, now)
+	for _, rule := range rules {
+		if rule.matches(content, age, hasAge, state) {
+			return rule.CacheControl
+		}
+	}
+	return defaultPolicy
+}
+
+// contentAge returns the time elapsed since the most recent of the publishedDate and lastModified of the content.
+func contentAge(content map[string]interface{}, now time.Time) (time.Duration, bool) {
+	var latest time.Time
+	for _, field := range []string{"publishedDate", "lastModified"} {
+		value, ok := content[field].(string)
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			continue
+		}
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	if latest.IsZero() {
+		return 0, false
+	}
+	return now.Sub(latest), true
+}
+
+func hasAnyType(content map[string]interface{}, types []string) bool {
+	var contentTypes []interface{}
+	if t, ok := content["types"].([]interface{}); ok {
+		contentTypes = t
+	}
+	if t, ok := content["type"].(string); ok {
+		contentTypes = append(contentTypes, t)
+	}
+	for _, contentType := range contentTypes {
+		for _, t := range types {
+			if contentType == t {
+				return true
+			}
+		}
+	}
+	return false
+}
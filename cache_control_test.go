@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCacheControlRules = `[
+	{"types": ["http://www.ft.com/ontology/content/LiveBlogPackage"], "cacheControl": "max-age=30"},
+	{"partial": true, "cacheControl": "max-age=10"},
+	{"minAge": "8760h", "cacheControl": "max-age=86400"},
+	{"maxAge": "1h", "cacheControl": "max-age=60"}
+]`
+
+func TestSelectCacheControl(t *testing.T) {
+	rules, err := parseCacheControlRules(testCacheControlRules)
+	assert.NoError(t, err)
+
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	partial := newResponseState()
+	partial.markPartial()
+
+	data := []struct {
+		name         string
+		content      map[string]interface{}
+		state        *responseState
+		cacheControl string
+	}{
+		{
+			"liveblog by types",
+			map[string]interface{}{"types": []interface{}{"http://www.ft.com/ontology/content/LiveBlogPackage"}, "publishedDate": "2014-01-29T12:39:06.000Z"},
+			partial,
+			"max-age=30",
+		},
+		{
+			"partial response",
+			map[string]interface{}{"publishedDate": "2014-01-29T12:39:06.000Z"},
+			partial,
+			"max-age=10",
+		},
+		{
+			"old article",
+			map[string]interface{}{"publishedDate": "2014-01-29T12:39:06.000Z"},
+			newResponseState(),
+			"max-age=86400",
+		},
+		{
+			"recently modified old article",
+			map[string]interface{}{"publishedDate": "2014-01-29T12:39:06.000Z", "lastModified": "2020-06-01T11:30:00.000Z"},
+			newResponseState(),
+			"max-age=60",
+		},
+		{
+			"no rule matches",
+			map[string]interface{}{"publishedDate": "2020-05-01T11:30:00.000Z"},
+			newResponseState(),
+			"no-store",
+		},
+		{
+			"no dates never match age rules",
+			map[string]interface{}{},
+			newResponseState(),
+			"no-store",
+		},
+	}
+
+	for _, row := range data {
+		assert.Equal(t, row.cacheControl, selectCacheControl(rules, "no-store", row.content, row.state, now), row.name)
+	}
+}
+
+func TestParseCacheControlRules(t *testing.T) {
+	rules, err := parseCacheControlRules("")
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+
+	_, err = parseCacheControlRules(`[{"minAge": "a year", "cacheControl": "max-age=86400"}]`)
+	assert.Error(t, err)
+
+	_, err = parseCacheControlRules(`{"cacheControl": "max-age=86400"}`)
+	assert.Error(t, err)
+
+	_, err = parseCacheControlRules(`[{"stale": true, "cacheControl": "max-age=5"}]`)
+	assert.Error(t, err, "An unknown condition should be rejected")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const inconsistentHeader = "X-Content-Inconsistent"
+
+// sourceVersion is the version of the content a source provided, kept before the source specific fields are filtered out.
+type sourceVersion struct {
+	lastModified     string
+	publishReference string
+}
+
+func contentVersion(content map[string]interface{}) sourceVersion {
+	lastModified, _ := content["lastModified"].(string)
+	publishReference, _ := content["publishReference"].(string)
+	return sourceVersion{lastModified, publishReference}
+}
+
+// versionsDisagree tells whether the two versions come from different publishes that are further apart than the threshold.
+// Versions missing any of the fields cannot be compared and are considered consistent.
+func versionsDisagree(a sourceVersion, b sourceVersion, threshold time.Duration) (time.Duration, bool) {
+	if a.publishReference == "" || b.publishReference == "" || a.publishReference == b.publishReference {
+		return 0, false
+	}
+	lastModifiedA, errA := time.Parse(time.RFC3339Nano, a.lastModified)
+	lastModifiedB, errB := time.Parse(time.RFC3339Nano, b.lastModified)
+	if errA != nil || errB != nil {
+		return 0, false
+	}
+	drift := lastModifiedA.Sub(lastModifiedB)
+	if drift < 0 {
+		drift = -drift
+	}
+	return drift, drift > threshold
+}
+
+// checkConsistency compares the version of the content source with the versions of the optional sources,
+// recording the disagreements in the metrics, the logs and, when configured, the response state.
+func (h internalContentHandler) checkConsistency(ctx context.Context, parts []responsePart, tid string) {
+	if len(parts) == 0 {
+		return
+	}
+	uuid := contentUUID(ctx)
+	for _, p := range parts[1:] {
+		drift, disagree := versionsDisagree(parts[0].version, p.version, h.serviceConfig.consistencyThreshold)
+		if !disagree {
+			continue
+		}
+		h.metrics.recordInconsistentEvent()
+		h.log.InconsistentSourcesEvent(parts[0].upstream, parts[0].version, p.upstream, p.version, drift, tid, uuid)
+		if h.serviceConfig.flagInconsistent {
+			responseStateFrom(ctx).markInconsistent()
+		}
+	}
+}
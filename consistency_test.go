@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentVersion(t *testing.T) {
+	assert.Equal(t, sourceVersion{"2017-02-27T14:23:14.709Z", "tid_1"}, contentVersion(map[string]interface{}{"lastModified": "2017-02-27T14:23:14.709Z", "publishReference": "tid_1", "title": "Title"}))
+	assert.Equal(t, sourceVersion{}, contentVersion(nil))
+}
+
+func TestVersionsDisagree(t *testing.T) {
+	data := []struct {
+		name          string
+		a             sourceVersion
+		b             sourceVersion
+		expected      bool
+		expectedDrift time.Duration
+	}{
+		{"same publish", sourceVersion{"2017-02-27T14:23:14.709Z", "tid_1"}, sourceVersion{"2017-02-27T12:00:00.000Z", "tid_1"}, false, 0},
+		{"publishes within threshold", sourceVersion{"2017-02-27T14:23:14.709Z", "tid_1"}, sourceVersion{"2017-02-27T14:23:00.709Z", "tid_2"}, false, 14 * time.Second},
+		{"publishes beyond threshold", sourceVersion{"2017-02-27T14:23:14.709Z", "tid_1"}, sourceVersion{"2017-02-27T14:20:14.709Z", "tid_2"}, true, 3 * time.Minute},
+		{"older content source", sourceVersion{"2017-02-27T14:20:14.709Z", "tid_1"}, sourceVersion{"2017-02-27T14:23:14.709Z", "tid_2"}, true, 3 * time.Minute},
+		{"missing publish reference", sourceVersion{"2017-02-27T14:23:14.709Z", "tid_1"}, sourceVersion{"2017-02-27T10:00:00.000Z", ""}, false, 0},
+		{"invalid last modified", sourceVersion{"2017-02-27T14:23:14.709Z", "tid_1"}, sourceVersion{"yesterday", "tid_2"}, false, 0},
+	}
+
+	for _, row := range data {
+		drift, disagree := versionsDisagree(row.a, row.b, time.Minute)
+		assert.Equal(t, row.expected, disagree, row.name)
+		assert.Equal(t, row.expectedDrift, drift, row.name)
+	}
+}
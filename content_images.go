@@ -0,0 +1,177 @@
+package main
+
+import "fmt"
+
+// contentImage is an image of the content, such as its main image, one of its lead images or the image model a lead
+// image is expanded with. Its fields other than its identifier, type and URLs are merged as they are.
+type contentImage struct {
+	ID         string
+	Type       string
+	RequestURL string
+	APIURL     string
+	// Image is the image model of a lead image expanded by the content unroller, nil when it has none
+	Image *contentImage
+	// fields holds the other fields of the image, along with the typed ones which were found empty or null
+	fields map[string]interface{}
+}
+
+// alternativeImages are the images of the content used in place of its main image.
+type alternativeImages struct {
+	PromotionalImage *contentImage
+	fields           map[string]interface{}
+}
+
+func (i *contentImage) stringFields() map[string]*string {
+	return map[string]*string{
+		"id":         &i.ID,
+		"type":       &i.Type,
+		"requestUrl": &i.RequestURL,
+		"apiUrl":     &i.APIURL,
+	}
+}
+
+// newContentImage reads an image decoded from JSON into the typed model, failing when a field of the model has an
+// unexpected type.
+func newContentImage(key string, value interface{}) (*contentImage, error) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s should be an object, got %T", key, value)
+	}
+	image := &contentImage{fields: make(map[string]interface{}, len(m))}
+	stringFields := image.stringFields()
+	for k, v := range m {
+		if field, found := stringFields[k]; found {
+			s, err := stringValue(key+" "+k, v)
+			if err != nil {
+				return nil, err
+			}
+			if s != "" {
+				*field = s
+				continue
+			}
+		}
+		if k == "image" && v != nil {
+			model, err := newContentImage(key+" image", v)
+			if err != nil {
+				return nil, err
+			}
+			image.Image = model
+			continue
+		}
+		image.fields[k] = v
+	}
+	return image, nil
+}
+
+func newContentImages(key string, value interface{}) ([]contentImage, error) {
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s should be an array, got %T", key, value)
+	}
+	images := make([]contentImage, 0, len(values))
+	for i, v := range values {
+		image, err := newContentImage(fmt.Sprintf("%s %d", key, i), v)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, *image)
+	}
+	return images, nil
+}
+
+func newAlternativeImages(value interface{}) (*alternativeImages, error) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("alternativeImages should be an object, got %T", value)
+	}
+	images := &alternativeImages{fields: make(map[string]interface{}, len(m))}
+	for k, v := range m {
+		if k == "promotionalImage" && v != nil {
+			image, err := newContentImage("promotionalImage", v)
+			if err != nil {
+				return nil, err
+			}
+			images.PromotionalImage = image
+			continue
+		}
+		images.fields[k] = v
+	}
+	return images, nil
+}
+
+// merge merges b into the image: the identifier, type and URLs of b override the ones of the image, even when empty
+// or null, while its image model and other fields are merged field by field.
+func (i *contentImage) merge(b *contentImage) {
+	fields := i.stringFields()
+	for key, field := range b.stringFields() {
+		if *field != "" {
+			*fields[key] = *field
+			delete(i.fields, key)
+		} else if _, found := b.fields[key]; found {
+			*fields[key] = ""
+		}
+	}
+	switch {
+	case b.Image == nil:
+		if _, found := b.fields["image"]; found {
+			i.Image = nil
+		}
+	case i.Image == nil:
+		i.Image = b.Image
+		delete(i.fields, "image")
+	default:
+		i.Image.merge(b.Image)
+	}
+	i.fields = mergeTwoContents(i.fields, b.fields)
+}
+
+func (a *alternativeImages) merge(b *alternativeImages) {
+	switch {
+	case b.PromotionalImage == nil:
+		if _, found := b.fields["promotionalImage"]; found {
+			a.PromotionalImage = nil
+		}
+	case a.PromotionalImage == nil:
+		a.PromotionalImage = b.PromotionalImage
+		delete(a.fields, "promotionalImage")
+	default:
+		a.PromotionalImage.merge(b.PromotionalImage)
+	}
+	a.fields = mergeTwoContents(a.fields, b.fields)
+}
+
+// toMap returns the image in the shape it is decoded from JSON.
+func (i *contentImage) toMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(i.fields)+5)
+	for key, value := range i.fields {
+		m[key] = value
+	}
+	for key, field := range i.stringFields() {
+		if *field != "" {
+			m[key] = *field
+		}
+	}
+	if i.Image != nil {
+		m["image"] = i.Image.toMap()
+	}
+	return m
+}
+
+func contentImagesToMaps(images []contentImage) []interface{} {
+	maps := make([]interface{}, len(images))
+	for i := range images {
+		maps[i] = images[i].toMap()
+	}
+	return maps
+}
+
+func (a *alternativeImages) toMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(a.fields)+1)
+	for key, value := range a.fields {
+		m[key] = value
+	}
+	if a.PromotionalImage != nil {
+		m["promotionalImage"] = a.PromotionalImage.toMap()
+	}
+	return m
+}
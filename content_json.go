@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+const maxPooledBufferSize = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	// very large buffers are left to the garbage collector so that a single big document does not pin its memory
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// decodeContent decodes the content in a single pass.
+func decodeContent(b []byte) (map[string]interface{}, error) {
+	var content map[string]interface{}
+	if err := json.Unmarshal(b, &content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// marshalJSON encodes the value like json.Marshal does, through a pooled buffer.
+func marshalJSON(v interface{}) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	// the encoder terminates the value with a newline, which json.Marshal does not
+	encoded := make([]byte, buf.Len()-1)
+	copy(encoded, buf.Bytes())
+	return encoded, nil
+}
+
+// contentString returns a string field of the content.
+func contentString(content map[string]interface{}, key string) (string, bool) {
+	value, ok := content[key].(string)
+	return value, ok
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeContent(t *testing.T) {
+	content, err := decodeContent([]byte(`{"title": "Title", "bodyXML": "<body><p>Text</p></body>", "annotations": [{"id": "http://api.ft.com/things/1"}], "types": ["Article"]}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"title":       "Title",
+		"bodyXML":     "<body><p>Text</p></body>",
+		"annotations": []interface{}{map[string]interface{}{"id": "http://api.ft.com/things/1"}},
+		"types":       []interface{}{"Article"},
+	}, content)
+}
+
+func TestDecodeContentFailsOnInvalidJSON(t *testing.T) {
+	_, err := decodeContent([]byte(`{"title": `))
+	assert.Error(t, err)
+
+	_, err = decodeContent([]byte(`["not", "an", "object"]`))
+	assert.Error(t, err)
+}
+
+func TestContentString(t *testing.T) {
+	content := map[string]interface{}{"bodyXML": "<body></body>", "title": 1}
+
+	bodyXML, ok := contentString(content, "bodyXML")
+	assert.True(t, ok)
+	assert.Equal(t, "<body></body>", bodyXML)
+	_, ok = contentString(content, "title")
+	assert.False(t, ok)
+	_, ok = contentString(content, "missing")
+	assert.False(t, ok)
+}
+
+func TestRemoveEmptyFieldsCleansTheAnnotations(t *testing.T) {
+	content, err := decodeContent([]byte(`{"title": "Title", "annotations": [{"id": "http://api.ft.com/things/1", "prefLabel": "", "type": null, "predicate": "about"}]}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"title":       "Title",
+		"annotations": []interface{}{map[string]interface{}{"id": "http://api.ft.com/things/1", "predicate": "about"}},
+	}, removeEmptyMapFields(content))
+	assert.Equal(t, map[string]interface{}{
+		"title":       "Title",
+		"annotations": []interface{}{map[string]interface{}{"id": "http://api.ft.com/things/1", "prefLabel": "", "predicate": "about"}},
+	}, removeEmptyFields(content, emptyFieldPolicy{keep: parseFieldPaths("annotations.prefLabel")}))
+}
+
+func TestMarshalJSONMatchesJSONMarshal(t *testing.T) {
+	content, err := decodeContent([]byte(`{"title": "<Title> & more", "bodyXML": "<body><p></body>", "types": ["Article"]}`))
+	require.NoError(t, err)
+
+	expected, err := json.Marshal(content)
+	require.NoError(t, err)
+	actual, err := marshalJSON(content)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual))
+}
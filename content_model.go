@@ -0,0 +1,421 @@
+package main
+
+import "fmt"
+
+// internalContent is the InternalContent schema of api/api.yml. The fields which are not part of the typed model,
+// such as the nested objects which are merged field by field, are kept in extra
+// so that they go through unchanged.
+type internalContent struct {
+	ID                 string
+	UUID               string
+	Title              string
+	Standfirst         string
+	Byline             string
+	FirstPublishedDate string
+	PublishedDate      string
+	WebURL             string
+	RequestURL         string
+	APIURL             string
+	PublishReference   string
+	LastModified       string
+	CanBeDistributed   string
+	CanBeSyndicated    string
+	AccessLevel        string
+	EditorialDesk      string
+	PrefLabel          string
+	Types              []string
+	// Embeds is nil when the content has no embeds field
+	Embeds            []contentEmbed
+	MainImage         *contentImage
+	AlternativeImages *alternativeImages
+	LeadImages        []contentImage
+	// empty holds the typed fields which were found empty or null, with their value,
+	// so that they override the ones of the content they are merged into and survive toMap
+	empty map[string]interface{}
+	extra map[string]interface{}
+}
+
+// contentEmbed is an embedded content, its fields other than the identifiers and the nested embeds being merged
+// as they are.
+type contentEmbed struct {
+	ID   string
+	UUID string
+	// Embeds are the embeds of the embedded content, nil when it has none
+	Embeds []contentEmbed
+	fields map[string]interface{}
+	// filter holds the fields removed from the embed by its source, embedsComponentsFilter when nil
+	filter []string
+}
+
+func (c *internalContent) stringFields() map[string]*string {
+	return map[string]*string{
+		"id":                 &c.ID,
+		"uuid":               &c.UUID,
+		"title":              &c.Title,
+		"standfirst":         &c.Standfirst,
+		"byline":             &c.Byline,
+		"firstPublishedDate": &c.FirstPublishedDate,
+		"publishedDate":      &c.PublishedDate,
+		"webUrl":             &c.WebURL,
+		"requestUrl":         &c.RequestURL,
+		"apiUrl":             &c.APIURL,
+		"publishReference":   &c.PublishReference,
+		"lastModified":       &c.LastModified,
+		"canBeDistributed":   &c.CanBeDistributed,
+		"canBeSyndicated":    &c.CanBeSyndicated,
+		"accessLevel":        &c.AccessLevel,
+		"editorialDesk":      &c.EditorialDesk,
+		"prefLabel":          &c.PrefLabel,
+	}
+}
+
+// newInternalContent reads the content decoded from JSON into the typed model,
+// failing when a field of the model has an unexpected type.
+func newInternalContent(m map[string]interface{}) (*internalContent, error) {
+	c := &internalContent{empty: make(map[string]interface{}), extra: make(map[string]interface{})}
+	stringFields := c.stringFields()
+	for key, value := range m {
+		if field, found := stringFields[key]; found {
+			s, err := stringValue(key, value)
+			if err != nil {
+				return nil, err
+			}
+			*field = s
+			if s == "" {
+				c.empty[key] = value
+			}
+			continue
+		}
+		if value == nil && imageFields[key] {
+			c.empty[key] = value
+			continue
+		}
+		switch key {
+		case "types":
+			types, err := stringValues(key, value)
+			if err != nil {
+				return nil, err
+			}
+			c.Types = types
+		case "embeds":
+			embeds, err := newContentEmbeds(value)
+			if err != nil {
+				return nil, err
+			}
+			c.Embeds = embeds
+		case "mainImage":
+			image, err := newContentImage(key, value)
+			if err != nil {
+				return nil, err
+			}
+			c.MainImage = image
+		case "alternativeImages":
+			images, err := newAlternativeImages(value)
+			if err != nil {
+				return nil, err
+			}
+			c.AlternativeImages = images
+		case "leadImages":
+			images, err := newContentImages(key, value)
+			if err != nil {
+				return nil, err
+			}
+			c.LeadImages = images
+		default:
+			c.extra[key] = value
+		}
+	}
+	return c, nil
+}
+
+// imageFields are the image fields of the typed model, which are kept in empty when null.
+var imageFields = map[string]bool{
+	"mainImage":         true,
+	"alternativeImages": true,
+	"leadImages":        true,
+}
+
+func newContentEmbeds(value interface{}) ([]contentEmbed, error) {
+	if value == nil {
+		return []contentEmbed{}, nil
+	}
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("embeds should be an array, got %T", value)
+	}
+	embeds := make([]contentEmbed, 0, len(values))
+	for i, v := range values {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("embed %d should be an object, got %T", i, v)
+		}
+		embed := contentEmbed{fields: make(map[string]interface{})}
+		for key, value := range m {
+			var err error
+			switch key {
+			case "id":
+				embed.ID, err = stringValue("embed id", value)
+			case "uuid":
+				embed.UUID, err = stringValue("embed uuid", value)
+			case "embeds":
+				if value == nil {
+					embed.fields[key] = value
+					break
+				}
+				embed.Embeds, err = newContentEmbeds(value)
+			default:
+				embed.fields[key] = value
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		embeds = append(embeds, embed)
+	}
+	return embeds, nil
+}
+
+func stringValue(key string, value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s should be a string, got %T", key, value)
+	}
+	return s, nil
+}
+
+func stringValues(key string, value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s should be an array, got %T", key, value)
+	}
+	strings := make([]string, 0, len(values))
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s should only hold strings, got %T", key, v)
+		}
+		strings = append(strings, s)
+	}
+	return strings, nil
+}
+
+// toMap returns the content in the shape it is decoded from JSON, leaving out the typed fields it did not have.
+func (c *internalContent) toMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(c.extra)+len(c.stringFields())+5)
+	for key, value := range c.extra {
+		m[key] = value
+	}
+	for key, value := range c.empty {
+		m[key] = value
+	}
+	for key, field := range c.stringFields() {
+		if *field != "" {
+			m[key] = *field
+		}
+	}
+	if c.Types != nil {
+		types := make([]interface{}, len(c.Types))
+		for i, t := range c.Types {
+			types[i] = t
+		}
+		m["types"] = types
+	}
+	if c.Embeds != nil {
+		embeds := make([]interface{}, len(c.Embeds))
+		for i, e := range c.Embeds {
+			embeds[i] = e.toMap()
+		}
+		m["embeds"] = embeds
+	}
+	if c.MainImage != nil {
+		m["mainImage"] = c.MainImage.toMap()
+	}
+	if c.AlternativeImages != nil {
+		m["alternativeImages"] = c.AlternativeImages.toMap()
+	}
+	if c.LeadImages != nil {
+		m["leadImages"] = contentImagesToMaps(c.LeadImages)
+	}
+	return m
+}
+
+func (e contentEmbed) toMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(e.fields)+2)
+	for key, value := range e.fields {
+		m[key] = value
+	}
+	if e.ID != "" {
+		m["id"] = e.ID
+	}
+	if e.UUID != "" {
+		m["uuid"] = e.UUID
+	}
+	if e.Embeds != nil {
+		embeds := make([]interface{}, len(e.Embeds))
+		for i, nested := range e.Embeds {
+			embeds[i] = nested.toMap()
+		}
+		m["embeds"] = embeds
+	}
+	return m
+}
+
+// mergeContents merges b into a: the fields of b override the ones of a, even when empty or null, while the embeds,
+// the images and the nested objects are merged field by field and the lead images of b replace the ones of a.
+// The embeds are normalised to API URLs when both contents have some.
+func mergeContents(a *internalContent, b *internalContent, baseURL string) (*internalContent, error) {
+	if a.empty == nil {
+		a.empty = make(map[string]interface{})
+	}
+	aFields := a.stringFields()
+	for key, field := range b.stringFields() {
+		if *field != "" {
+			*aFields[key] = *field
+			delete(a.empty, key)
+		} else if value, found := b.empty[key]; found {
+			*aFields[key] = ""
+			a.empty[key] = value
+		}
+	}
+	if b.Types != nil {
+		a.Types = b.Types
+	}
+	if b.Embeds != nil {
+		if a.Embeds == nil {
+			a.Embeds = b.Embeds
+		} else {
+			embeds, err := mergeEmbeds(a.Embeds, b.Embeds, baseURL)
+			if err != nil {
+				return nil, err
+			}
+			a.Embeds = embeds
+		}
+	}
+	a.mergeImages(b)
+	a.extra = mergeTwoContents(a.extra, b.extra)
+	return a, nil
+}
+
+func (a *internalContent) mergeImages(b *internalContent) {
+	_, nullMainImage := b.empty["mainImage"]
+	switch {
+	case b.MainImage != nil && a.MainImage != nil:
+		a.MainImage.merge(b.MainImage)
+	case b.MainImage != nil:
+		a.MainImage = b.MainImage
+		delete(a.empty, "mainImage")
+	case nullMainImage:
+		a.MainImage = nil
+		a.empty["mainImage"] = nil
+	}
+	_, nullAlternativeImages := b.empty["alternativeImages"]
+	switch {
+	case b.AlternativeImages != nil && a.AlternativeImages != nil:
+		a.AlternativeImages.merge(b.AlternativeImages)
+	case b.AlternativeImages != nil:
+		a.AlternativeImages = b.AlternativeImages
+		delete(a.empty, "alternativeImages")
+	case nullAlternativeImages:
+		a.AlternativeImages = nil
+		a.empty["alternativeImages"] = nil
+	}
+	_, nullLeadImages := b.empty["leadImages"]
+	switch {
+	case b.LeadImages != nil:
+		a.LeadImages = b.LeadImages
+		delete(a.empty, "leadImages")
+	case nullLeadImages:
+		a.LeadImages = nil
+		a.empty["leadImages"] = nil
+	}
+}
+
+func mergeEmbeds(a []contentEmbed, b []contentEmbed, baseURL string) ([]contentEmbed, error) {
+	for i := range a {
+		if err := a[i].normalise(baseURL); err != nil {
+			return nil, err
+		}
+	}
+	for i := range b {
+		if err := b[i].normalise(baseURL); err != nil {
+			return nil, err
+		}
+	}
+	if len(a) == 0 {
+		return b, nil
+	}
+	if len(b) == 0 {
+		return a, nil
+	}
+	for _, embedB := range b {
+		found := false
+		for i, embedA := range a {
+			if sameIds(embedB.ID, embedA.ID) {
+				if err := a[i].merge(embedB, baseURL); err != nil {
+					return nil, err
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			a = append(a, embedB)
+		}
+	}
+	return a, nil
+}
+
+// merge merges the fields of b into the embed, its nested embeds being merged like the embeds of the content.
+func (e *contentEmbed) merge(b contentEmbed, baseURL string) error {
+	e.fields = mergeTwoContents(e.fields, b.fields)
+	switch {
+	case b.Embeds == nil:
+		if _, found := b.fields["embeds"]; found {
+			e.Embeds = nil
+		}
+	case e.Embeds == nil:
+		e.Embeds = b.Embeds
+		delete(e.fields, "embeds")
+	default:
+		embeds, err := mergeEmbeds(e.Embeds, b.Embeds, baseURL)
+		if err != nil {
+			return err
+		}
+		e.Embeds = embeds
+	}
+	return nil
+}
+
+// normalise turns the identifier of the embed into its API URL and renames its request URLs to API URLs.
+func (e *contentEmbed) normalise(baseURL string) error {
+	filter := e.filter
+	if filter == nil {
+		filter = embedsComponentsFilter
+	}
+	e.fields = filterEmbedsKeys(e.fields, filter)
+	switch {
+	case e.UUID != "":
+		e.ID = baseURL + e.UUID
+		e.UUID = ""
+	case e.ID != "":
+		e.ID = baseURL + extractIDValue(e.ID)
+	default:
+		return fmt.Errorf("embed has neither an id nor a uuid")
+	}
+	e.renameRequestURLs()
+	return nil
+}
+
+// renameRequestURLs renames the request URLs of the embed and of its nested embeds to API URLs.
+func (e *contentEmbed) renameRequestURLs() {
+	e.fields = renameKey("requestUrl", "apiUrl", e.fields)
+	for i := range e.Embeds {
+		e.Embeds[i].renameRequestURLs()
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInternalContentRoundTrip(t *testing.T) {
+	for _, fixture := range []string{
+		"test-resources/enriched-content-api-output.json",
+		"test-resources/enriched-content-api-output-unrollContent.json",
+		"test-resources/content-public-read-output-unrollContent.json",
+		"test-resources/embedded-enrichedcontent-output.json",
+		"test-resources/full-expanded-internal-content-api-output.json",
+	} {
+		contentJSON, err := ioutil.ReadFile(fixture)
+		assert.NoError(t, err, fixture)
+
+		var expected map[string]interface{}
+		assert.NoError(t, json.Unmarshal(contentJSON, &expected), fixture)
+
+		content, err := newInternalContent(expected)
+		assert.NoError(t, err, fixture)
+		assert.NotEmpty(t, content.ID, fixture)
+		assert.Equal(t, expected, content.toMap(), fixture)
+	}
+}
+
+func TestNewInternalContentRejectsUnexpectedTypes(t *testing.T) {
+	data := []struct {
+		name    string
+		content map[string]interface{}
+	}{
+		{"title is not a string", map[string]interface{}{"title": 1.0}},
+		{"types is not an array", map[string]interface{}{"types": "http://www.ft.com/ontology/content/Article"}},
+		{"types holds a non string", map[string]interface{}{"types": []interface{}{1.0}}},
+		{"embeds is not an array", map[string]interface{}{"embeds": map[string]interface{}{}}},
+		{"embed is not an object", map[string]interface{}{"embeds": []interface{}{"1"}}},
+		{"embed id is not a string", map[string]interface{}{"embeds": []interface{}{map[string]interface{}{"id": 1.0}}}},
+		{"nested embed id is not a string", map[string]interface{}{"embeds": []interface{}{map[string]interface{}{"id": "1", "embeds": []interface{}{map[string]interface{}{"id": 1.0}}}}}},
+		{"mainImage is not an object", map[string]interface{}{"mainImage": "http://api.ft.com/content/1"}},
+		{"mainImage id is not a string", map[string]interface{}{"mainImage": map[string]interface{}{"id": 1.0}}},
+		{"promotionalImage is not an object", map[string]interface{}{"alternativeImages": map[string]interface{}{"promotionalImage": "1"}}},
+		{"leadImages is not an array", map[string]interface{}{"leadImages": map[string]interface{}{}}},
+		{"lead image is not an object", map[string]interface{}{"leadImages": []interface{}{"1"}}},
+		{"lead image model is not an object", map[string]interface{}{"leadImages": []interface{}{map[string]interface{}{"id": "1", "image": "1"}}}},
+	}
+
+	for _, row := range data {
+		_, err := newInternalContent(row.content)
+		assert.Error(t, err, row.name)
+	}
+}
+
+func TestMergeContents(t *testing.T) {
+	a, err := newInternalContent(map[string]interface{}{
+		"id":    "http://www.ft.com/thing/1",
+		"title": "Title",
+		"embeds": []interface{}{
+			map[string]interface{}{"id": "http://www.ft.com/thing/2", "title": "Embed", "requestUrl": "http://api.ft.com/content/2"},
+		},
+		"alternativeTitles": map[string]interface{}{"promotionalTitle": "Promo"},
+	})
+	assert.NoError(t, err)
+	b, err := newInternalContent(map[string]interface{}{
+		"title": "New title",
+		"embeds": []interface{}{
+			map[string]interface{}{"uuid": "2", "description": "Description"},
+			map[string]interface{}{"uuid": "3"},
+		},
+		"alternativeTitles": map[string]interface{}{"shortTeaser": "Teaser"},
+	})
+	assert.NoError(t, err)
+
+	merged, err := mergeContents(a, b, testBaseURL)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":    "http://www.ft.com/thing/1",
+		"title": "New title",
+		"embeds": []interface{}{
+			map[string]interface{}{"id": testBaseURL + "2", "title": "Embed", "description": "Description"},
+			map[string]interface{}{"id": testBaseURL + "3"},
+		},
+		"alternativeTitles": map[string]interface{}{"promotionalTitle": "Promo", "shortTeaser": "Teaser"},
+	}, merged.toMap())
+}
+
+func TestMergeContentsOverridesWithEmptyFields(t *testing.T) {
+	a, err := newInternalContent(map[string]interface{}{"title": "Title", "standfirst": "Old standfirst", "byline": "Byline", "editorialDesk": "Desk"})
+	assert.NoError(t, err)
+	b, err := newInternalContent(map[string]interface{}{"standfirst": "", "byline": nil, "prefLabel": ""})
+	assert.NoError(t, err)
+
+	merged, err := mergeContents(a, b, testBaseURL)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"title":         "Title",
+		"standfirst":    "",
+		"byline":        nil,
+		"editorialDesk": "Desk",
+		"prefLabel":     "",
+	}, merged.toMap())
+
+	c, err := newInternalContent(map[string]interface{}{"byline": "New byline"})
+	assert.NoError(t, err)
+	merged, err = mergeContents(merged, c, testBaseURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "New byline", merged.toMap()["byline"])
+}
+
+func TestMergeContentsMergesTheImages(t *testing.T) {
+	a, err := newInternalContent(map[string]interface{}{
+		"mainImage":         map[string]interface{}{"id": "http://api.ft.com/content/1", "title": "Main"},
+		"alternativeImages": map[string]interface{}{"promotionalImage": map[string]interface{}{"id": "http://api.ft.com/content/2"}},
+		"leadImages":        []interface{}{map[string]interface{}{"id": "3", "type": "square"}},
+	})
+	assert.NoError(t, err)
+	b, err := newInternalContent(map[string]interface{}{
+		"mainImage":         map[string]interface{}{"title": "", "description": "Description"},
+		"alternativeImages": map[string]interface{}{"promotionalImage": map[string]interface{}{"type": "promo"}},
+		"leadImages":        []interface{}{map[string]interface{}{"id": "4", "type": "wide"}},
+	})
+	assert.NoError(t, err)
+
+	merged, err := mergeContents(a, b, testBaseURL)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"mainImage":         map[string]interface{}{"id": "http://api.ft.com/content/1", "title": "", "description": "Description"},
+		"alternativeImages": map[string]interface{}{"promotionalImage": map[string]interface{}{"id": "http://api.ft.com/content/2", "type": "promo"}},
+		"leadImages":        []interface{}{map[string]interface{}{"id": "4", "type": "wide"}},
+	}, merged.toMap())
+
+	c, err := newInternalContent(map[string]interface{}{"mainImage": nil, "leadImages": nil})
+	assert.NoError(t, err)
+	merged, err = mergeContents(merged, c, testBaseURL)
+	assert.NoError(t, err)
+	assert.Nil(t, merged.MainImage)
+	assert.Nil(t, merged.LeadImages)
+	assert.Equal(t, map[string]interface{}{
+		"mainImage":         nil,
+		"alternativeImages": map[string]interface{}{"promotionalImage": map[string]interface{}{"id": "http://api.ft.com/content/2", "type": "promo"}},
+		"leadImages":        nil,
+	}, merged.toMap())
+}
+
+func TestMergeContentsMergesTheNestedEmbeds(t *testing.T) {
+	a, err := newInternalContent(map[string]interface{}{"embeds": []interface{}{
+		map[string]interface{}{"id": "http://www.ft.com/thing/1", "embeds": []interface{}{
+			map[string]interface{}{"id": "http://www.ft.com/thing/2", "title": "Nested"},
+		}},
+	}})
+	assert.NoError(t, err)
+	b, err := newInternalContent(map[string]interface{}{"embeds": []interface{}{
+		map[string]interface{}{"uuid": "1", "embeds": []interface{}{
+			map[string]interface{}{"uuid": "2", "requestUrl": "http://api.ft.com/content/2"},
+			map[string]interface{}{"uuid": "3"},
+		}},
+	}})
+	assert.NoError(t, err)
+
+	merged, err := mergeContents(a, b, testBaseURL)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": testBaseURL + "1", "embeds": []interface{}{
+			map[string]interface{}{"id": testBaseURL + "2", "title": "Nested", "apiUrl": "http://api.ft.com/content/2"},
+			map[string]interface{}{"id": testBaseURL + "3"},
+		}},
+	}, merged.toMap()["embeds"])
+
+	c, err := newInternalContent(map[string]interface{}{"embeds": []interface{}{
+		map[string]interface{}{"uuid": "1", "embeds": []interface{}{map[string]interface{}{"title": "No id"}}},
+	}})
+	assert.NoError(t, err)
+	_, err = mergeContents(merged, c, testBaseURL)
+	assert.Error(t, err)
+}
+
+func TestMergeContentsFailsForEmbedWithoutID(t *testing.T) {
+	a, err := newInternalContent(map[string]interface{}{"embeds": []interface{}{map[string]interface{}{"id": "1"}}})
+	assert.NoError(t, err)
+	b, err := newInternalContent(map[string]interface{}{"embeds": []interface{}{map[string]interface{}{"title": "No id"}}})
+	assert.NoError(t, err)
+
+	_, err = mergeContents(a, b, testBaseURL)
+	assert.Error(t, err)
+}
+
+func TestMergePartsNamesTheSourceOfInvalidContent(t *testing.T) {
+	_, err := mergeParts([]responsePart{
+		{upstream: "content-source", content: map[string]interface{}{"id": "1"}},
+		{upstream: "internal-components", content: map[string]interface{}{"embeds": "none"}},
+	}, testBaseURL)
+	assert.EqualError(t, err, "invalid content from internal-components: embeds should be an array, got string")
+}
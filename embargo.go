@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+	"golang.org/x/net/context"
+)
+
+const embargoCacheControl = "private, no-store"
+
+// embargoTime returns the publish time of the content when it is in the future, taken from the most recent
+// of its publishedDate and firstPublishedDate.
+func embargoTime(content map[string]interface{}, now time.Time) (time.Time, bool) {
+	var embargo time.Time
+	for _, field := range []string{"publishedDate", "firstPublishedDate"} {
+		value, ok := content[field].(string)
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			continue
+		}
+		if t.After(embargo) {
+			embargo = t
+		}
+	}
+	return embargo, embargo.After(now)
+}
+
+// isPrivileged tells whether one of the access policies of the request lets the caller see embargoed content.
+func (h internalContentHandler) isPrivileged(r *http.Request) bool {
+	policies := parsePolicyHeader(r.Header.Get(h.serviceConfig.accessPolicyHeader))
+	for p := range h.serviceConfig.embargoPrivilegedPolicies {
+		if policies[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// guardEmbargo returns the problem to respond with for content under embargo until the given time: 404 as for
+// content which does not exist yet, or 403 for the privileged callers which did not ask to override the embargo.
+// The responses are cached until the embargo time only. The privileged callers overriding the embargo get the
+// content, which is then kept out of the caches.
+func (h internalContentHandler) guardEmbargo(ctx context.Context, w http.ResponseWriter, r *http.Request, embargo time.Time, now time.Time) *Problem {
+	uuid := contentUUID(ctx)
+	tid, _ := transactionidutils.GetTransactionIDFromContext(ctx)
+	privileged := h.isPrivileged(r)
+	override, _ := strconv.ParseBool(r.Header.Get(h.serviceConfig.embargoOverrideHeader))
+	h.log.EmbargoEvent(r.RequestURI, tid, uuid, embargo, privileged && override)
+	if privileged && override {
+		responseStateFrom(ctx).markEmbargoed()
+		return nil
+	}
+	h.setEmbargoCacheHeaders(w, uuid, embargo.Sub(now))
+	if privileged {
+		problem := newProblem(embargoedProblem, http.StatusForbidden,
+			fmt.Sprintf("Content is under embargo until %s, set the %s header to see it", embargo.UTC().Format(time.RFC3339), h.serviceConfig.embargoOverrideHeader), tid)
+		return &problem
+	}
+	problem := newProblem(contentNotFoundProblem, http.StatusNotFound, "Content was not found", tid)
+	return &problem
+}
+
+// setEmbargoCacheHeaders lets caches keep the response of embargoed content until the embargo time at the latest,
+// tagged with the content uuid so that it can be purged. The response varies with the access policies and the
+// override header of the request, which tell between the 404 and the 403.
+func (h internalContentHandler) setEmbargoCacheHeaders(w http.ResponseWriter, uuid string, remaining time.Duration) {
+	vary := h.varyHeader()
+	if h.serviceConfig.embargoOverrideHeader != "" {
+		vary += ", " + h.serviceConfig.embargoOverrideHeader
+	}
+	w.Header().Set("Vary", vary)
+	w.Header().Set("Cache-Control", capCacheControl(h.serviceConfig.cacheControlPolicy, remaining))
+	w.Header().Set("Surrogate-Key", uuid)
+	if h.serviceConfig.surrogateControlPolicy != "" {
+		w.Header().Set("Surrogate-Control", capCacheControl(h.serviceConfig.surrogateControlPolicy, remaining))
+	}
+}
+
+// capCacheControl lowers the max-age and s-maxage of the policy to the remaining time, adding a max-age when the
+// policy has none. The stale-while-revalidate and stale-if-error directives are dropped, as they would let caches
+// serve the response after the remaining time.
+func capCacheControl(policy string, remaining time.Duration) string {
+	limit := int(math.Ceil(remaining.Seconds()))
+	var directives []string
+	hasMaxAge := false
+	for _, directive := range strings.Split(policy, ",") {
+		directive = strings.TrimSpace(directive)
+		name, value, _ := strings.Cut(directive, "=")
+		switch strings.ToLower(name) {
+		case "":
+			continue
+		case "stale-while-revalidate", "stale-if-error":
+			continue
+		case "max-age", "s-maxage":
+			hasMaxAge = hasMaxAge || strings.ToLower(name) == "max-age"
+			if seconds, err := strconv.Atoi(value); err != nil || seconds > limit {
+				directive = name + "=" + strconv.Itoa(limit)
+			}
+		}
+		directives = append(directives, directive)
+	}
+	if !hasMaxAge {
+		directives = append(directives, "max-age="+strconv.Itoa(limit))
+	}
+	return strings.Join(directives, ", ")
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestEmbargoTime(t *testing.T) {
+	now := time.Date(2017, time.January, 17, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name      string
+		content   map[string]interface{}
+		expected  time.Time
+		embargoed bool
+	}{
+		{
+			name:      "published content",
+			content:   map[string]interface{}{"publishedDate": "2017-01-17T11:00:00.000Z"},
+			expected:  time.Date(2017, time.January, 17, 11, 0, 0, 0, time.UTC),
+			embargoed: false,
+		},
+		{
+			name:      "content published in the future",
+			content:   map[string]interface{}{"publishedDate": "2017-01-17T13:00:00.000Z", "firstPublishedDate": "2017-01-17T11:00:00.000Z"},
+			expected:  time.Date(2017, time.January, 17, 13, 0, 0, 0, time.UTC),
+			embargoed: true,
+		},
+		{
+			name:      "content first published in the future",
+			content:   map[string]interface{}{"publishedDate": "2017-01-17T11:00:00.000Z", "firstPublishedDate": "2017-01-17T13:00:00Z"},
+			expected:  time.Date(2017, time.January, 17, 13, 0, 0, 0, time.UTC),
+			embargoed: true,
+		},
+		{
+			name:      "content without a valid publish date",
+			content:   map[string]interface{}{"publishedDate": "tomorrow", "firstPublishedDate": 1484658000},
+			embargoed: false,
+		},
+	}
+	for _, tc := range testCases {
+		embargo, embargoed := embargoTime(tc.content, now)
+		assert.Equal(t, tc.embargoed, embargoed, tc.name)
+		assert.True(t, tc.expected.Equal(embargo), "%s: expected %s, got %s", tc.name, tc.expected, embargo)
+	}
+}
+
+func TestCapCacheControl(t *testing.T) {
+	testCases := []struct {
+		policy    string
+		remaining time.Duration
+		expected  string
+	}{
+		{"max-age=10", time.Hour, "max-age=10"},
+		{"max-age=3600, public", 90 * time.Second, "max-age=90, public"},
+		{"max-age=10, stale-while-revalidate=60, stale-if-error=3600", time.Hour, "max-age=10"},
+		{"public, s-maxage=600", 1500 * time.Millisecond, "public, s-maxage=2, max-age=2"},
+		{"", time.Minute, "max-age=60"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, capCacheControl(tc.policy, tc.remaining), "Unexpected policy for %q", tc.policy)
+	}
+}
+
+func TestGuardEmbargo(t *testing.T) {
+	now := time.Date(2017, time.January, 17, 12, 0, 0, 0, time.UTC)
+	embargo := now.Add(5 * time.Minute)
+	testCases := []struct {
+		name         string
+		headers      map[string]string
+		status       int
+		problemType  string
+		cacheControl string
+	}{
+		{"public caller", nil, http.StatusNotFound, "content-not-found", "max-age=300"},
+		{"public caller overriding the embargo", map[string]string{"X-Embargo-Override": "true"}, http.StatusNotFound, "content-not-found", "max-age=300"},
+		{"privileged caller", map[string]string{"X-Policy": "INTERNAL_UNSTABLE"}, http.StatusForbidden, "content-embargoed", "max-age=300"},
+		{"privileged caller overriding the embargo", map[string]string{"X-Policy": "PARTNER, INTERNAL_UNSTABLE", "X-Embargo-Override": "true"}, 0, "", ""},
+	}
+	for _, tc := range testCases {
+		logger, hook := test.NewNullLogger()
+		h := internalContentHandler{
+			serviceConfig: &serviceConfig{
+				cacheControlPolicy:        "max-age=3600, stale-while-revalidate=60",
+				surrogateControlPolicy:    "max-age=86400",
+				accessPolicyHeader:        "X-Policy",
+				embargoPrivilegedPolicies: map[string]bool{"INTERNAL_UNSTABLE": true},
+				embargoOverrideHeader:     "X-Embargo-Override",
+			},
+			log: &appLogger{logger},
+		}
+		ctx := context.WithValue(context.Background(), uuidKey, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+		state := newResponseState()
+		ctx = context.WithValue(ctx, responseStateKey, state)
+		r := httptest.NewRequest(http.MethodGet, "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", nil)
+		for name, value := range tc.headers {
+			r.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+
+		problem := h.guardEmbargo(ctx, w, r, embargo, now)
+
+		require.Len(t, hook.Entries, 1, tc.name)
+		assert.Equal(t, "embargo", hook.LastEntry().Data["event"], tc.name)
+		assert.Equal(t, "2017-01-17T12:05:00Z", hook.LastEntry().Data["embargo"], tc.name)
+		if tc.status == 0 {
+			assert.Nil(t, problem, tc.name)
+			assert.True(t, state.isEmbargoed(), tc.name)
+			assert.Equal(t, true, hook.LastEntry().Data["overridden"], tc.name)
+			assert.Empty(t, w.Header().Get("Cache-Control"), tc.name)
+			continue
+		}
+		require.NotNil(t, problem, tc.name)
+		assert.Equal(t, tc.status, problem.Status, tc.name)
+		assert.Contains(t, problem.Type, tc.problemType, tc.name)
+		assert.False(t, state.isEmbargoed(), tc.name)
+		assert.Equal(t, tc.cacheControl, w.Header().Get("Cache-Control"), tc.name)
+		assert.Equal(t, "max-age=300", w.Header().Get("Surrogate-Control"), tc.name)
+		assert.Equal(t, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", w.Header().Get("Surrogate-Key"), tc.name)
+		assert.Equal(t, "Accept, X-Policy, X-Embargo-Override", w.Header().Get("Vary"), tc.name)
+	}
+}
+
+func TestVaryHeaderListsTheAccessPolicyHeaderOfTheEmbargo(t *testing.T) {
+	h := internalContentHandler{serviceConfig: &serviceConfig{accessPolicyHeader: "X-Policy", embargoPrivilegedPolicies: map[string]bool{"INTERNAL_UNSTABLE": true}}}
+	assert.Equal(t, "Accept, X-Policy", h.varyHeader())
+}
+
+func TestEmbargoedContentIsNotCached(t *testing.T) {
+	h := internalContentHandler{serviceConfig: &serviceConfig{cacheControlPolicy: "max-age=10", surrogateControlPolicy: "max-age=86400"}}
+	state := newResponseState()
+	state.markEmbargoed()
+	ctx := context.WithValue(context.Background(), uuidKey, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+	ctx = context.WithValue(ctx, responseStateKey, state)
+	w := httptest.NewRecorder()
+
+	h.setCacheHeaders(ctx, w, map[string]interface{}{"id": "http://www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce"})
+
+	assert.Equal(t, embargoCacheControl, w.Header().Get("Cache-Control"))
+	assert.Empty(t, w.Header().Get("Surrogate-Control"))
+	assert.Contains(t, w.Header().Get("Surrogate-Key"), "5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+}
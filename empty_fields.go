@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	keepEmptyKey       contextKey = "keepEmpty"
+	dropEmptyArraysKey contextKey = "dropEmptyArrays"
+)
+
+// emptyFieldPolicy tells which empty fields are removed from the responses. The nil, empty string and empty object
+// fields are removed unless their path, such as byline or topper.headline, is kept. The empty arrays are only removed
+// when dropEmptyArrays is set. The path of the elements of an array is the path of the array.
+type emptyFieldPolicy struct {
+	keep            map[string]bool
+	dropEmptyArrays bool
+}
+
+// parseFieldPaths parses a comma separated list of field paths, such as byline,topper.headline.
+func parseFieldPaths(paths string) map[string]bool {
+	parsed := make(map[string]bool)
+	for _, path := range strings.Split(paths, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			parsed[path] = true
+		}
+	}
+	return parsed
+}
+
+func (p emptyFieldPolicy) keptPaths() []string {
+	paths := make([]string, 0, len(p.keep))
+	for path := range p.keep {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (p emptyFieldPolicy) isKept(path string) bool {
+	return p.keep[path]
+}
+
+func (p emptyFieldPolicy) fieldPath(path string, key string) string {
+	if len(p.keep) == 0 {
+		return ""
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// requestEmptyFieldPolicy returns the configured policy, extended with the keepEmpty fields of the profile and
+// of the request and overridden by the dropEmptyArrays of the profile and then of the request.
+func (h internalContentHandler) requestEmptyFieldPolicy(r *http.Request, profile responseProfile) (emptyFieldPolicy, error) {
+	configured := h.serviceConfig.emptyFieldPolicy
+	policy := emptyFieldPolicy{keep: make(map[string]bool, len(configured.keep)), dropEmptyArrays: configured.dropEmptyArrays}
+	for path := range configured.keep {
+		policy.keep[path] = true
+	}
+	for _, path := range profile.KeepEmpty {
+		policy.keep[path] = true
+	}
+	if profile.DropEmptyArrays != nil {
+		policy.dropEmptyArrays = *profile.DropEmptyArrays
+	}
+	for path := range parseFieldPaths(r.URL.Query().Get(keepEmptyKey.String())) {
+		policy.keep[path] = true
+	}
+	if value := r.URL.Query().Get(dropEmptyArraysKey.String()); value != "" {
+		drop, err := strconv.ParseBool(value)
+		if err != nil {
+			return policy, fmt.Errorf("invalid %s parameter %q: %v", dropEmptyArraysKey, value, err)
+		}
+		policy.dropEmptyArrays = drop
+	}
+	return policy, nil
+}
+
+func emptyFieldPolicyFrom(ctx context.Context) emptyFieldPolicy {
+	policy, _ := ctx.Value(keepEmptyKey).(emptyFieldPolicy)
+	return policy
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFieldPaths(t *testing.T) {
+	assert.Equal(t, map[string]bool{"byline": true, "topper.headline": true}, parseFieldPaths(" byline, ,topper.headline"))
+	assert.Empty(t, parseFieldPaths(""))
+}
+
+func TestRemoveEmptyFieldsKeepsTheFieldsOfThePolicy(t *testing.T) {
+	content := map[string]interface{}{
+		"byline":     "",
+		"standfirst": nil,
+		"title":      "",
+		"topper":     map[string]interface{}{"headline": "", "standfirst": ""},
+		"embeds":     []interface{}{map[string]interface{}{"title": nil, "id": "1"}},
+		"bodyXML":    "",
+	}
+	policy := emptyFieldPolicy{keep: parseFieldPaths("byline,standfirst,topper.headline,embeds.title,bodyXML")}
+
+	assert.Equal(t, map[string]interface{}{
+		"byline":     "",
+		"standfirst": nil,
+		"topper":     map[string]interface{}{"headline": ""},
+		"embeds":     []interface{}{map[string]interface{}{"title": nil, "id": "1"}},
+		"bodyXML":    "",
+	}, removeEmptyFields(content, policy))
+}
+
+func TestRemoveEmptyFieldsKeepsAnEmptyObjectOfThePolicy(t *testing.T) {
+	content := map[string]interface{}{"alternativeTitles": map[string]interface{}{"promotionalTitle": ""}}
+
+	assert.Equal(t, map[string]interface{}{"alternativeTitles": map[string]interface{}{}},
+		removeEmptyFields(content, emptyFieldPolicy{keep: parseFieldPaths("alternativeTitles")}))
+}
+
+func TestRemoveEmptyFieldsDropsEmptyArrays(t *testing.T) {
+	content := map[string]interface{}{
+		"containedIn":           []interface{}{},
+		"curatedRelatedContent": []interface{}{map[string]interface{}{"id": ""}},
+		"types":                 []interface{}{"Article"},
+		"annotations":           []interface{}{},
+		"nested":                []interface{}{[]interface{}{}, "value"},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"types":  []interface{}{"Article"},
+		"nested": []interface{}{"value"},
+	}, removeEmptyFields(content, emptyFieldPolicy{dropEmptyArrays: true}))
+}
+
+func TestRemoveEmptyFieldsKeepsAnEmptyArrayOfThePolicy(t *testing.T) {
+	content := map[string]interface{}{"containedIn": []interface{}{}, "curatedRelatedContent": []interface{}{}}
+
+	assert.Equal(t, map[string]interface{}{"containedIn": []interface{}{}},
+		removeEmptyFields(content, emptyFieldPolicy{keep: parseFieldPaths("containedIn"), dropEmptyArrays: true}))
+}
+
+func TestRequestEmptyFieldPolicy(t *testing.T) {
+	h := internalContentHandler{serviceConfig: &serviceConfig{emptyFieldPolicy: emptyFieldPolicy{keep: parseFieldPaths("byline"), dropEmptyArrays: true}}}
+
+	policy, err := h.requestEmptyFieldPolicy(httptest.NewRequest("GET", "/internalcontent/1?keepEmpty=standfirst&dropEmptyArrays=false", nil), responseProfile{})
+	require.NoError(t, err)
+	assert.Equal(t, emptyFieldPolicy{keep: parseFieldPaths("byline,standfirst")}, policy)
+	assert.Equal(t, parseFieldPaths("byline"), h.serviceConfig.emptyFieldPolicy.keep, "the configured policy should not be modified")
+
+	policy, err = h.requestEmptyFieldPolicy(httptest.NewRequest("GET", "/internalcontent/1", nil), responseProfile{})
+	require.NoError(t, err)
+	assert.Equal(t, h.serviceConfig.emptyFieldPolicy, policy)
+
+	_, err = h.requestEmptyFieldPolicy(httptest.NewRequest("GET", "/internalcontent/1?dropEmptyArrays=sometimes", nil), responseProfile{})
+	assert.Error(t, err)
+}
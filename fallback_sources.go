@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+const contentSourceHeader = "X-Content-Source"
+
+// fallbackSource is a content source tried in turn when the previous source of the chain did not provide the content.
+type fallbackSource struct {
+	AppName string `json:"appName"`
+	AppURI  string `json:"appURI"`
+}
+
+func parseFallbackSources(sources string) ([]fallbackSource, error) {
+	var parsed []fallbackSource
+	if sources == "" {
+		return parsed, nil
+	}
+	err := json.Unmarshal([]byte(sources), &parsed)
+	return parsed, err
+}
+
+func (h internalContentHandler) contentFallbacks() []retriever {
+	var fallbacks []retriever
+	for _, s := range h.serviceConfig.contentFallbacks {
+		fallbacks = append(fallbacks, retriever{uri: s.AppURI, sourceAppName: s.AppName, doFail: true, transformContent: transformContentSourceContent})
+	}
+	return fallbacks
+}
+
+func (h internalContentHandler) isContentSource(appName string) bool {
+	if appName == h.serviceConfig.content.appName {
+		return true
+	}
+	for _, s := range h.serviceConfig.contentFallbacks {
+		if appName == s.AppName {
+			return true
+		}
+	}
+	return false
+}
+
+// retrieveWithFallbacks tries the sources of the retriever in order until one of them provides the content.
+// Deleted content is not looked up further, as the source knows it has been deleted.
+func (h internalContentHandler) retrieveWithFallbacks(ctx context.Context, r retriever, uuid string, tid string) responsePart {
+	part := h.retrieveAndUnmarshall(ctx, r, uuid, tid)
+	for _, fallback := range r.fallbacks {
+		if !shouldFallback(part) {
+			break
+		}
+		h.log.FallbackEvent(part.upstream, fallback.sourceAppName, tid, uuid)
+		part = h.retrieveAndUnmarshall(ctx, fallback, uuid, tid)
+	}
+	return part
+}
+
+func shouldFallback(part responsePart) bool {
+	if part.statusCode == http.StatusGone {
+		return false
+	}
+	return !part.isOk || part.e.err != nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFallbackSources(t *testing.T) {
+	sources, err := parseFallbackSources(`[{"appName": "content-public-read", "appURI": "http://localhost:8080/__content-public-read/content/"}]`)
+	assert.NoError(t, err)
+	assert.Equal(t, []fallbackSource{{AppName: "content-public-read", AppURI: "http://localhost:8080/__content-public-read/content/"}}, sources)
+
+	sources, err = parseFallbackSources("")
+	assert.NoError(t, err)
+	assert.Empty(t, sources)
+
+	_, err = parseFallbackSources(`{"appName": "content-public-read"}`)
+	assert.Error(t, err)
+}
+
+func TestShouldFallback(t *testing.T) {
+	data := []struct {
+		name     string
+		part     responsePart
+		expected bool
+	}{
+		{"content found", responsePart{isOk: true, statusCode: http.StatusOK}, false},
+		{"content not found", responsePart{isOk: false, statusCode: http.StatusNotFound}, true},
+		{"source not available", responsePart{isOk: false, statusCode: http.StatusServiceUnavailable}, true},
+		{"invalid response", responsePart{isOk: true, statusCode: http.StatusOK, e: event{err: errors.New("invalid json")}}, true},
+		{"content deleted", responsePart{isOk: false, statusCode: http.StatusGone}, false},
+	}
+
+	for _, row := range data {
+		assert.Equal(t, row.expected, shouldFallback(row.part), row.name)
+	}
+}
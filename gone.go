@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// isTombstone tells whether the given content is the tombstone left by an upstream for deleted content,
+// flagged either with "deleted": true or with its deletion date.
+func isTombstone(content map[string]interface{}) bool {
+	if deleted, ok := content["deleted"].(bool); ok && deleted {
+		return true
+	}
+	deletedDate, _ := content["deletedDate"].(string)
+	return strings.TrimSpace(deletedDate) != ""
+}
+
+// setGoneCacheHeaders lets caches keep the 410 of deleted content for the configured time only,
+// and tags it with the content uuid so that it can be purged when the content is republished.
+// No Surrogate-Control is set, as the CDN would obey it over the gone policy.
+func (h internalContentHandler) setGoneCacheHeaders(w http.ResponseWriter, uuid string) {
+	w.Header().Set("Cache-Control", h.serviceConfig.goneCacheControlPolicy)
+	w.Header().Set("Surrogate-Key", uuid)
+}
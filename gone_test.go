@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTombstone(t *testing.T) {
+	data := []struct {
+		name     string
+		content  map[string]interface{}
+		expected bool
+	}{
+		{"deleted flag", map[string]interface{}{"id": "1", "deleted": true}, true},
+		{"deleted date", map[string]interface{}{"id": "1", "deletedDate": "2017-01-18T10:00:00.000Z"}, true},
+		{"deleted flag false", map[string]interface{}{"id": "1", "deleted": false}, false},
+		{"empty deleted date", map[string]interface{}{"id": "1", "deletedDate": " "}, false},
+		{"published content", map[string]interface{}{"id": "1", "title": "Title"}, false},
+		{"no content", nil, false},
+	}
+
+	for _, row := range data {
+		assert.Equal(t, row.expected, isTombstone(row.content), row.name)
+	}
+}
+
+func TestSetGoneCacheHeaders(t *testing.T) {
+	h := internalContentHandler{serviceConfig: &serviceConfig{goneCacheControlPolicy: "max-age=60", surrogateControlPolicy: "max-age=86400"}}
+	w := httptest.NewRecorder()
+
+	h.setGoneCacheHeaders(w, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+
+	assert.Equal(t, "max-age=60", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", w.Header().Get("Surrogate-Key"))
+	assert.Empty(t, w.Header().Get("Surrogate-Control"), "The CDN should follow the gone policy")
+}
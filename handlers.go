@@ -1,12 +1,12 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"sync"
+	"time"
 
 	"bytes"
 	"errors"
@@ -21,10 +21,15 @@ import (
 )
 
 const (
-	uuidKey          contextKey = "uuid"
-	unrollContentKey contextKey = "unrollContent"
+	uuidKey            contextKey = "uuid"
+	unrollContentKey   contextKey = "unrollContent"
+	inlineEmbedsKey    contextKey = "inlineEmbeds"
+	bodyFormatKey      contextKey = "bodyFormat"
+	readingMetadataKey contextKey = "readingMetadata"
 )
 
+// internalComponentsFilter and embedsComponentsFilter are the default filters of the internal components
+// and of the embeds, used for the sources without a configured filter.
 var internalComponentsFilter = map[string]interface{}{
 	"id":               "",
 	"uuid":             "",
@@ -40,16 +45,18 @@ type internalContentHandler struct {
 	metrics       *Metrics
 }
 
-type ResponseMessage struct {
-	Message string `json:"message"`
-}
-
 type responsePart struct {
-	isOk       bool
-	statusCode int
-	failMsg    string
-	e          event
-	content    map[string]interface{}
+	isOk           bool
+	statusCode     int
+	failMsg        string
+	failure        problemType
+	upstream       string
+	upstreamStatus int
+	e              event
+	content        map[string]interface{}
+	version        sourceVersion
+	// embedsFilter holds the fields removed from the embeds of the content by its source
+	embedsFilter []string
 }
 
 type transformContent func(ctx context.Context, content map[string]interface{}, h internalContentHandler) map[string]interface{}
@@ -59,6 +66,7 @@ type retriever struct {
 	sourceAppName string
 	doFail        bool
 	transformContent
+	fallbacks []retriever
 }
 
 type contextKey string
@@ -68,9 +76,7 @@ func transformContentSourceContent(ctx context.Context, content map[string]inter
 }
 
 func transformInternalComponentsContent(ctx context.Context, content map[string]interface{}, h internalContentHandler) map[string]interface{} {
-	transformedContent := h.unrollContent(ctx, content)
-	transformedContent = filterKeys(transformedContent, internalComponentsFilter)
-	return transformedContent
+	return h.unrollContent(ctx, content)
 }
 
 func (c contextKey) String() string {
@@ -78,57 +84,186 @@ func (c contextKey) String() string {
 }
 
 func (h internalContentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	uuid := mux.Vars(r)["uuid"]
-	err := validateUUID(uuid)
+	if canonical, err := canonicalUUID(uuid); err == nil && canonical != uuid {
+		http.Redirect(w, r, canonicalContentPath(h.serviceConfig.handlerPath, canonical, r.URL.Query()), http.StatusMovedPermanently)
+		return
+	}
+	h.serveContent(w, r, uuid)
+}
+
+func (h internalContentHandler) serveContent(w http.ResponseWriter, r *http.Request, uuid string) {
+	ctx, mergedContent, ok := h.retrieveMergedContent(w, r, uuid)
+	if !ok {
+		return
+	}
+	// the cache headers are computed from what the caller may see, so that they do not reveal the restricted fields
+	visibleContent := h.restrictFields(ctx, r, h.resolveAdditionalFields(ctx, mergedContent))
+	profile := profileFrom(ctx)
+	renderer := profile.renderer(r)
+	resultBytes, err := renderer.render(profile.project(visibleContent))
+	transactionID, _ := transactionidutils.GetTransactionIDFromContext(ctx)
+	if errors.Is(err, errNotSyndicatable) {
+		writeProblem(w, newProblem(notSyndicatableProblem, http.StatusForbidden, "The canBeSyndicated field of the content is not yes", transactionID))
+		return
+	}
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		msg, _ := json.Marshal(ResponseMessage{fmt.Sprintf("The given uuid is not valid, err=%v", err)})
-		_, _ = w.Write(msg)
+		h.handleError(err, h.serviceConfig.appName, r.RequestURI, transactionID, contentUUID(ctx))
+		writeProblem(w, newProblem(renderingProblem, http.StatusInternalServerError, "Failed to render the content", transactionID))
 		return
 	}
+	w.Header().Set("Content-Type", renderer.contentType)
+	w.Header().Set("Vary", h.varyHeader())
+	h.setCacheHeaders(ctx, w, visibleContent)
+	w.WriteHeader(responseStateFrom(ctx).statusCode())
+	_, _ = w.Write(resultBytes)
+	h.metrics.recordResponseEvent()
+}
 
+// retrieveMergedContent validates the requested uuid, retrieves the content from the sources and merges it.
+// When the content cannot be retrieved, the error response is written and false is returned.
+func (h internalContentHandler) retrieveMergedContent(w http.ResponseWriter, r *http.Request, uuid string) (context.Context, map[string]interface{}, bool) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	tid := transactionidutils.GetTransactionIDFromRequest(r)
-	h.log.TransactionStartedEvent(r.RequestURI, tid, uuid)
-
-	unrollContentParam := r.URL.Query().Get(unrollContentKey.String())
-	unrollContent, err := strconv.ParseBool(unrollContentParam)
+	err := validateUUID(uuid)
+	if err != nil {
+		p := newProblem(invalidUUIDProblem, http.StatusBadRequest, fmt.Sprintf("The given uuid is not valid, err=%v", err), tid)
+		p.UUID = uuid
+		writeProblem(w, p)
+		return nil, nil, false
+	}
+	wait, err := h.parsePublishWait(r)
+	if err != nil {
+		writeProblem(w, newProblem(invalidParameterProblem, http.StatusBadRequest, err.Error(), tid))
+		return nil, nil, false
+	}
+	profile, err := h.requestProfile(r)
 	if err != nil {
-		unrollContent = false
+		writeProblem(w, newProblem(invalidParameterProblem, http.StatusBadRequest, err.Error(), tid))
+		return nil, nil, false
 	}
+	policy, err := h.requestEmptyFieldPolicy(r, profile)
+	if err != nil {
+		writeProblem(w, newProblem(invalidParameterProblem, http.StatusBadRequest, err.Error(), tid))
+		return nil, nil, false
+	}
+
+	h.log.TransactionStartedEvent(r.RequestURI, tid, uuid)
+
+	unrollContent := profile.unrollContent(r)
 
 	ctx := context.WithValue(transactionidutils.TransactionAwareContext(context.Background(), tid), uuidKey, uuid)
 	ctx = context.WithValue(ctx, unrollContentKey, unrollContent)
+	ctx = context.WithValue(ctx, inlineEmbedsKey, parseBoolParam(r, inlineEmbedsKey))
+	ctx = context.WithValue(ctx, bodyFormatKey, r.URL.Query().Get(bodyFormatKey.String()))
+	ctx = context.WithValue(ctx, readingMetadataKey, h.serviceConfig.readingMetadata || parseBoolParam(r, readingMetadataKey))
+	ctx = context.WithValue(ctx, keepEmptyKey, policy)
+	ctx = context.WithValue(ctx, profileKey, profile)
+	ctx = context.WithValue(ctx, entitledKey, h.isEntitled(r))
+
+	ctx, mergedContent, problem := h.waitForPublishReference(ctx, r, wait, uuid, tid)
+	if problem != nil {
+		if problem.Status == http.StatusGone {
+			h.setGoneCacheHeaders(w, uuid)
+		}
+		writeProblem(w, *problem)
+		return nil, nil, false
+	}
+	now := time.Now()
+	if embargo, embargoed := embargoTime(mergedContent, now); embargoed {
+		if problem := h.guardEmbargo(ctx, w, r, embargo, now); problem != nil {
+			writeProblem(w, *problem)
+			return nil, nil, false
+		}
+	}
+	w.Header().Set(contentSourceHeader, responseStateFrom(ctx).contentSource())
+	if responseStateFrom(ctx).isInconsistent() {
+		w.Header().Set(inconsistentHeader, "true")
+	}
+	return ctx, mergedContent, true
+}
 
+// retrieveAndMerge retrieves the content from all the sources and merges it, recording the state of the response
+// in the context. It returns the problem to respond with when the content cannot be provided.
+func (h internalContentHandler) retrieveAndMerge(ctx context.Context, uuid string, tid string) (map[string]interface{}, *Problem) {
 	retrievers := []retriever{
-		{h.serviceConfig.content.appURI, h.serviceConfig.content.appName, true, transformContentSourceContent},
-		{h.serviceConfig.internalComponents.appURI, h.serviceConfig.internalComponents.appName, false, transformInternalComponentsContent},
+		{h.serviceConfig.content.appURI, h.serviceConfig.content.appName, true, transformContentSourceContent, h.contentFallbacks()},
+		{h.serviceConfig.internalComponents.appURI, h.serviceConfig.internalComponents.appName, false, transformInternalComponentsContent, nil},
 	}
 	parts := h.asyncRetrievalsAndUnmarshalls(ctx, retrievers, uuid, tid)
-	for _, p := range parts {
+	for i, p := range parts {
+		if !retrievers[i].doFail && p.content == nil {
+			responseStateFrom(ctx).markPartial()
+		}
 		if !p.isOk {
-			w.WriteHeader(p.statusCode)
-			if msg, err := json.Marshal(ResponseMessage{p.failMsg}); err == nil {
-				_, _ = w.Write(msg)
-			}
-			return
+			problem := newProblem(p.failure, p.statusCode, p.failMsg, tid).withUpstream(p.upstream, p.upstreamStatus)
+			return nil, &problem
 		}
 		if p.e.err != nil {
 			h.handleErrorEvent(p.e, "Error while unmarshaling the response body")
-			w.WriteHeader(http.StatusInternalServerError)
-			if msg, err := json.Marshal(ResponseMessage{"Failed to process service responses"}); err == nil {
-				_, _ = w.Write(msg)
-			}
-			return
+			problem := newProblem(invalidUpstreamProblem, http.StatusInternalServerError, "Failed to process service responses", tid).withUpstream(p.upstream, p.upstreamStatus)
+			return nil, &problem
+		}
+		if retrievers[i].doFail && isTombstone(p.content) {
+			h.metrics.recordDeletedEvent()
+			problem := newProblem(contentGoneProblem, http.StatusGone, fmt.Sprintf("Content was deleted from %s", p.upstream), tid).withUpstream(p.upstream, p.upstreamStatus)
+			return nil, &problem
 		}
 	}
+	responseStateFrom(ctx).setContentSource(parts[0].upstream)
+	h.checkConsistency(ctx, parts, tid)
 	baseURL := "https://" + h.serviceConfig.envAPIHost + "/content/"
-	mergedContent := mergeParts(parts, baseURL)
-	mergedContent = h.resolveAdditionalFields(ctx, mergedContent)
-	resultBytes, _ := json.Marshal(mergedContent)
-	w.Header().Set("Cache-Control", h.serviceConfig.cacheControlPolicy)
-	_, _ = w.Write(resultBytes)
-	h.metrics.recordResponseEvent()
+	merged, err := mergeParts(parts, baseURL)
+	if err != nil {
+		h.handleError(err, h.serviceConfig.appName, "", tid, uuid)
+		problem := newProblem(invalidUpstreamProblem, http.StatusBadGateway, err.Error(), tid)
+		return nil, &problem
+	}
+	return merged, nil
+}
+
+// contentUUID returns the uuid of the requested content, which is empty outside of a content request.
+func contentUUID(ctx context.Context) string {
+	uuid, _ := ctx.Value(uuidKey).(string)
+	return uuid
+}
+
+func parseBoolParam(r *http.Request, key contextKey) bool {
+	value, err := strconv.ParseBool(r.URL.Query().Get(key.String()))
+	if err != nil {
+		return false
+	}
+	return value
+}
+
+// idURLPrefixes are the hosts and paths of the id URLs a uuid can be given as, following their http or https scheme.
+var idURLPrefixes = []string{"www.ft.com/thing/", "api.ft.com/content/"}
+
+// canonicalUUID returns the canonical form of a uuid given in a variant form, such as upper case, braced, urn:uuid or
+// as an id URL like http://www.ft.com/thing/{uuid}.
+func canonicalUUID(identifier string) (string, error) {
+	parsedUUID, err := gouuid.Parse(trimIDURL(strings.TrimSpace(identifier)))
+	if err != nil {
+		return "", err
+	}
+	return parsedUUID.String(), nil
+}
+
+// trimIDURL returns the uuid of an id URL, or the identifier itself when it is not an id URL. The slashes following
+// the scheme can be collapsed, as the router cleans the double slashes of the request path.
+func trimIDURL(identifier string) string {
+	for _, scheme := range []string{"http:", "https:"} {
+		if len(identifier) < len(scheme) || !strings.EqualFold(identifier[:len(scheme)], scheme) {
+			continue
+		}
+		rest := strings.TrimLeft(identifier[len(scheme):], "/")
+		for _, prefix := range idURLPrefixes {
+			if strings.HasPrefix(rest, prefix) {
+				return strings.TrimPrefix(rest, prefix)
+			}
+		}
+	}
+	return identifier
 }
 
 func validateUUID(contentUUID string) error {
@@ -149,11 +284,10 @@ func (h internalContentHandler) asyncRetrievalsAndUnmarshalls(ctx context.Contex
 	wg.Add(len(retrievers))
 	for i, r := range retrievers {
 		go func(i int, r retriever) {
-			part := h.retrieveAndUnmarshall(ctx, r, uuid, tid)
+			defer wg.Done()
+			part := h.recoverRetrieval(ctx, r, uuid, tid)
 			m.Lock()
 			defer m.Unlock()
-			defer wg.Done()
-			part.content = r.transformContent(ctx, part.content, h)
 			responseParts[i] = part
 		}(i, r)
 	}
@@ -165,6 +299,11 @@ func (h internalContentHandler) retrieveAndUnmarshall(ctx context.Context, r ret
 	part, resp := h.callService(ctx, r)
 	defer cleanupResp(resp, h.log.log)
 
+	part.upstream = r.sourceAppName
+	if resp != nil {
+		part.upstreamStatus = resp.StatusCode
+	}
+
 	part.e = event{
 		requestURL:    extractRequestURL(resp),
 		transactionID: tid,
@@ -174,77 +313,137 @@ func (h internalContentHandler) retrieveAndUnmarshall(ctx context.Context, r ret
 	return part
 }
 
-func replaceUUID(content map[string]interface{}) {
+// replaceUUID returns the content with its uuid renamed to id, leaving the given content unchanged.
+func replaceUUID(content map[string]interface{}) map[string]interface{} {
 	recUUID, ok := content["uuid"]
-	if ok {
-		content["id"] = recUUID
-		delete(content, "uuid")
+	if !ok {
+		return content
 	}
+	replaced := copyMap(content)
+	replaced["id"] = recUUID
+	delete(replaced, "uuid")
+	return replaced
 }
 
 func (h internalContentHandler) unrollContent(ctx context.Context, content map[string]interface{}) map[string]interface{} {
 	var transformedContent map[string]interface{}
-	unrollContent := ctx.Value(unrollContentKey).(bool)
+	unrollContent, _ := ctx.Value(unrollContentKey).(bool)
 	if !unrollContent {
 		return content
 	}
-	replaceUUID(content)
+	content = replaceUUID(content)
 	var err error
 	transformedContent, err = h.getUnrolledContent(ctx, content)
 	if err != nil {
-		uuid := ctx.Value(uuidKey).(string)
+		uuid := contentUUID(ctx)
 		transactionID, _ := transactionidutils.GetTransactionIDFromContext(ctx)
 		h.handleError(err, h.serviceConfig.contentUnroller.appName, h.serviceConfig.contentUnroller.appURI, transactionID, uuid)
+		responseStateFrom(ctx).markPartial()
 		return content
 	}
 	return transformedContent
 }
 
 func (h internalContentHandler) resolveAdditionalFields(ctx context.Context, content map[string]interface{}) map[string]interface{} {
-	uuid := ctx.Value(uuidKey).(string)
+	uuid := contentUUID(ctx)
+	content = h.entitledContent(ctx, content)
 	content["requestUrl"] = createRequestURL(h.serviceConfig.envAPIHost, h.serviceConfig.handlerPath, uuid)
 	content["apiUrl"] = createRequestURL(h.serviceConfig.envAPIHost, h.serviceConfig.handlerPath, uuid)
-	removeEmptyMapFields(content)
-	return content
+	if readingMetadata, _ := ctx.Value(readingMetadataKey).(bool); readingMetadata {
+		addReadingMetadata(content, h.serviceConfig.summaryLength)
+	}
+	if bodyFormat, _ := ctx.Value(bodyFormatKey).(string); bodyFormat == bodyFormatMarkdown {
+		if bodyXML, ok := contentString(content, "bodyXML"); ok {
+			embeds, _ := content["embeds"].([]interface{})
+			content["bodyMarkdown"] = bodyXMLToMarkdown(bodyXML, embeds)
+		}
+	}
+	if inline, _ := ctx.Value(inlineEmbedsKey).(bool); inline {
+		inlineEmbeds(content)
+	}
+	return removeEmptyFields(content, emptyFieldPolicyFrom(ctx))
 }
 
+// copyMap returns a shallow copy of the map, which the copy-on-write operations below modify in place of their input.
+// The upstream results are shared between the operations, so that they are never modified.
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		copied[key] = value
+	}
+	return copied
+}
+
+// filterKeys returns the map without the keys of the filter, the maps held by both being filtered recursively.
+// The given map is left unchanged.
 func filterKeys(m map[string]interface{}, filter map[string]interface{}) map[string]interface{} {
+	filtered := m
+	copied := false
 	for key, valueInFilter := range filter {
 		foundValInM, foundInM := m[key]
 		if foundInM {
-			mapInM, isMapInM := foundValInM.(map[string]interface{})
+			if !copied {
+				filtered = copyMap(m)
+				copied = true
+			}
 			mapInFilter, isMapInFilter := valueInFilter.(map[string]interface{})
-			if isMapInM && isMapInFilter {
-				m[key] = filterKeys(mapInM, mapInFilter)
-			} else {
-				delete(m, key)
+			switch valInM := foundValInM.(type) {
+			case map[string]interface{}:
+				if isMapInFilter {
+					filtered[key] = filterKeys(valInM, mapInFilter)
+					continue
+				}
+			case []interface{}:
+				if isMapInFilter {
+					filtered[key] = filterSliceKeys(valInM, mapInFilter)
+					continue
+				}
 			}
+			delete(filtered, key)
 		}
 	}
-	return m
+	return filtered
 }
 
-func mergeParts(parts []responsePart, baseURL string) map[string]interface{} {
+// filterSliceKeys returns the slice with the keys of the filter removed from each of its objects.
+func filterSliceKeys(slice []interface{}, filter map[string]interface{}) []interface{} {
+	filtered := make([]interface{}, len(slice))
+	for i, elem := range slice {
+		if m, isMap := elem.(map[string]interface{}); isMap {
+			filtered[i] = filterKeys(m, filter)
+		} else {
+			filtered[i] = elem
+		}
+	}
+	return filtered
+}
+
+func mergeParts(parts []responsePart, baseURL string) (map[string]interface{}, error) {
 	if len(parts) == 0 {
-		return make(map[string]interface{})
+		return make(map[string]interface{}), nil
 	}
 	if len(parts) == 1 {
-		return parts[0].content
+		return copyMap(parts[0].content), nil
 	}
 
-	contents := make([]map[string]interface{}, len(parts))
-	for i, p := range parts {
-		if p.content != nil {
-			contents[i] = p.content
-		} else {
-			contents[i] = make(map[string]interface{})
+	var merged *internalContent
+	for _, p := range parts {
+		content, err := newInternalContent(p.content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content from %s: %w", p.upstream, err)
+		}
+		for i := range content.Embeds {
+			content.Embeds[i].filter = p.embedsFilter
+		}
+		if merged == nil {
+			merged = content
+			continue
+		}
+		if merged, err = mergeContents(merged, content, baseURL); err != nil {
+			return nil, fmt.Errorf("cannot merge the content from %s: %w", p.upstream, err)
 		}
 	}
-
-	for i := 1; i < len(contents); i++ {
-		contents[0] = mergeTwoContents(contents[0], contents[i], baseURL)
-	}
-	return contents[0]
+	return merged.toMap(), nil
 }
 
 func extractIDValue(id string) string {
@@ -260,120 +459,89 @@ func sameIds(idA string, idB string) bool {
 	return idA == idB || matchIDs(idA, idB)
 }
 
+// filterEmbedsKeys returns the map without the keys of the filter, leaving the given map unchanged.
 func filterEmbedsKeys(m map[string]interface{}, filter []string) map[string]interface{} {
+	filtered := m
+	copied := false
 	for _, valueInFilter := range filter {
 		_, foundInM := m[valueInFilter]
 		if foundInM {
-			delete(m, valueInFilter)
+			if !copied {
+				filtered = copyMap(m)
+				copied = true
+			}
+			delete(filtered, valueInFilter)
 		}
 	}
-	return m
+	return filtered
 }
 
+// renameKey returns the map with oldKey renamed to newKey at any depth, leaving the given map unchanged.
+// Only the maps and arrays holding a renamed key are copied.
 func renameKey(oldKey, newKey string, m map[string]interface{}) map[string]interface{} {
-	_, find := m[oldKey]
-	if find {
-		m[newKey] = m[oldKey]
-		delete(m, oldKey)
-	}
+	renamed, _ := renameKeyInMap(oldKey, newKey, m)
+	return renamed
+}
+
+func renameKeyInMap(oldKey, newKey string, m map[string]interface{}) (map[string]interface{}, bool) {
+	renamed := m
+	copied := false
 	for k, v := range m {
-		vi, find := v.([]interface{})
-		if find {
-			for _, v := range vi {
-				_value, _find := v.(map[string]interface{})
-				if _find {
-					renameKey(oldKey, newKey, _value)
-				}
+		var value interface{}
+		changed := false
+		switch typedVal := v.(type) {
+		case []interface{}:
+			value, changed = renameKeyInSlice(oldKey, newKey, typedVal)
+		case map[string]interface{}:
+			value, changed = renameKeyInMap(oldKey, newKey, typedVal)
+		}
+		if changed {
+			if !copied {
+				renamed = copyMap(m)
+				copied = true
 			}
+			renamed[k] = value
 		}
-		vMap, vMapHere := v.(map[string]interface{})
-		if vMapHere {
-			vMap = renameKey(oldKey, newKey, vMap)
-			m[k] = vMap
+	}
+	if value, find := renamed[oldKey]; find {
+		if !copied {
+			renamed = copyMap(m)
+			copied = true
 		}
+		renamed[newKey] = value
+		delete(renamed, oldKey)
 	}
-	return m
+	return renamed, copied
 }
 
-func transformEmbeds(vMap []interface{}, baseURL string) {
-	for _, valueMapB := range vMap {
-		valueMap, ok := valueMapB.(map[string]interface{})
-		if !ok {
-			return
+func renameKeyInSlice(oldKey, newKey string, s []interface{}) ([]interface{}, bool) {
+	var renamed []interface{}
+	for i, v := range s {
+		vMap, isMap := v.(map[string]interface{})
+		if !isMap {
+			continue
 		}
-		valueMap = filterEmbedsKeys(valueMap, embedsComponentsFilter)
-		id, ok := valueMap["id"]
-		if ok {
-			valueMap["id"] = baseURL + extractIDValue(id.(string))
-		}
-
-		uuid, ok := valueMap["uuid"]
-		if ok {
-			valueMap["id"] = baseURL + uuid.(string)
-			delete(valueMap, "uuid")
-		}
-		// Deep renaming
-		renameKey("requestUrl", "apiUrl", valueMap)
-	}
-}
-
-func mergeTwoEmbeds(a []interface{}, b []interface{}, baseURL string) []interface{} {
-	if len(a) == 0 {
-		return b
-	}
-	if len(b) == 0 {
-		return a
-	}
-	for _, valueInB := range b {
-		valueMapB, isMapInB := valueInB.(map[string]interface{})
-		if isMapInB {
-			lbFound := false
-			for aKey, valueInA := range a {
-				valueMapA, isMapInA := valueInA.(map[string]interface{})
-				if isMapInA {
-					if sameIds(valueMapB["id"].(string), valueMapA["id"].(string)) {
-						a[aKey] = mergeTwoContents(valueMapA, valueMapB, baseURL)
-						lbFound = true
-						break
-					}
-				}
-			}
-			if !lbFound {
-				a = append(a, valueMapB)
+		if value, changed := renameKeyInMap(oldKey, newKey, vMap); changed {
+			if renamed == nil {
+				renamed = append([]interface{}(nil), s...)
 			}
+			renamed[i] = value
 		}
 	}
-	return a
+	if renamed == nil {
+		return s, false
+	}
+	return renamed, true
 }
 
-func mergeTwoContents(a map[string]interface{}, b map[string]interface{}, baseURL string) map[string]interface{} {
+// mergeTwoContents returns the fields of b merged into the ones of a, leaving both unchanged.
+func mergeTwoContents(a map[string]interface{}, b map[string]interface{}) map[string]interface{} {
+	a = copyMap(a)
 	for key, valueInB := range b {
-		foundValInA, foundInA := a[key]
-		if foundInA {
-			if key == "embeds" {
-				arrInA, isArrInA := foundValInA.([]interface{})
-				if isArrInA {
-					transformEmbeds(arrInA, baseURL)
-				}
-				arrInB, isArrInB := valueInB.([]interface{})
-				if isArrInB {
-					transformEmbeds(arrInB, baseURL)
-				}
-
-				if isArrInA && isArrInB {
-					a[key] = mergeTwoEmbeds(arrInA, arrInB, baseURL)
-				} else {
-					a[key] = arrInB
-				}
-			} else {
-				mapInA, isMapInA := foundValInA.(map[string]interface{})
-				mapInB, isMapInB := valueInB.(map[string]interface{})
-				if isMapInA && isMapInB {
-					a[key] = mergeTwoContents(mapInA, mapInB, baseURL)
-				} else {
-					a[key] = valueInB
-				}
-			}
+		mapInA, isMapInA := a[key].(map[string]interface{})
+		mapInB, isMapInB := valueInB.(map[string]interface{})
+		if isMapInA && isMapInB {
+			a[key] = mergeTwoContents(mapInA, mapInB)
 		} else {
 			a[key] = valueInB
 		}
@@ -387,13 +555,14 @@ func (h internalContentHandler) expandLeadImages(ec map[string]interface{}) (map
 		return ec, errors.New("cannot find leadImages in response")
 	}
 
-	leadImagesAsArray := (leadImages).([]interface{})
-	for i := 0; i < len(leadImagesAsArray); i++ {
-		leadImageAsMap, ok := leadImagesAsArray[i].(map[string]interface{})
-		if ok {
-			h.transformLeadImage(leadImageAsMap)
-		}
+	images, err := newContentImages("leadImages", leadImages)
+	if err != nil {
+		return ec, err
 	}
+	for i := range images {
+		h.transformLeadImage(&images[i])
+	}
+	ec["leadImages"] = contentImagesToMaps(images)
 	return ec, nil
 }
 
@@ -403,7 +572,7 @@ func (h internalContentHandler) getUnrolledContent(ctx context.Context, content
 	if err != nil {
 		transactionID = transactionidutils.NewTransactionID()
 	}
-	body, err := json.Marshal(content)
+	body, err := marshalJSON(content)
 	if err != nil {
 		return expandedContent, err
 	}
@@ -420,7 +589,7 @@ func (h internalContentHandler) getUnrolledContent(ctx context.Context, content
 	}
 	defer resp.Body.Close()
 
-	uuid := ctx.Value(uuidKey).(string)
+	uuid := contentUUID(ctx)
 	if resp.StatusCode != http.StatusOK {
 		h.log.RequestFailedEvent(h.serviceConfig.contentUnroller.appName, req.URL.String(), resp, uuid)
 		h.metrics.recordRequestFailedEvent()
@@ -429,44 +598,42 @@ func (h internalContentHandler) getUnrolledContent(ctx context.Context, content
 	}
 	h.log.ResponseEvent(h.serviceConfig.contentUnroller.appName, req.URL.String(), resp, uuid)
 
-	body, err = ioutil.ReadAll(resp.Body)
-	if err != nil {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err = buf.ReadFrom(resp.Body); err != nil {
 		return expandedContent, err
 	}
 
-	err = json.Unmarshal(body, &expandedContent)
+	expandedContent, err = decodeContent(buf.Bytes())
 	if err != nil {
 		return expandedContent, err
 	}
 	return h.expandLeadImages(expandedContent)
 }
 
-func (h internalContentHandler) transformLeadImage(leadImage map[string]interface{}) {
-	if id, found := leadImage["id"]; found {
-		leadImage["id"] = "https://" + h.serviceConfig.envAPIHost + "/content/" + extractIDValue(id.(string))
+// transformLeadImage turns the identifier of the lead image into its API URL, and identifies its image model by the
+// API URL of its request URL, or of the lead image when it has none.
+func (h internalContentHandler) transformLeadImage(leadImage *contentImage) {
+	if leadImage.ID != "" {
+		leadImage.ID = "https://" + h.serviceConfig.envAPIHost + "/content/" + extractIDValue(leadImage.ID)
 	}
-	imageModel, found := leadImage["image"]
-	if !found {
-		//if image field is not found inside the image, continue the processing
-		return
-	}
-	var apiURL interface{}
-	imageModelAsMap, ok := imageModel.(map[string]interface{})
-	if !ok {
+	model := leadImage.Image
+	if model == nil {
 		return
 	}
-	if apiURL, found = imageModelAsMap["requestUrl"]; !found {
-		if apiURL, found = leadImage["id"]; !found {
-			return
-		}
+	apiURL := model.RequestURL
+	if _, found := model.fields["requestUrl"]; !found && apiURL == "" {
+		apiURL = leadImage.ID
 	}
-	apiURLAsString, ok := apiURL.(string)
-	if !ok {
+	if apiURL == "" {
 		return
 	}
-	imageModelAsMap["id"] = apiURLAsString
-	imageModelAsMap["apiUrl"] = apiURLAsString
-	delete(imageModelAsMap, "requestUrl")
+	model.ID = apiURL
+	model.APIURL = apiURL
+	model.RequestURL = ""
+	delete(model.fields, "requestUrl")
+	delete(model.fields, "id")
+	delete(model.fields, "apiUrl")
 }
 
 func createRequestURL(APIHost string, handlerPath string, uuid string) string {
@@ -474,19 +641,19 @@ func createRequestURL(APIHost string, handlerPath string, uuid string) string {
 }
 
 func (h internalContentHandler) callService(ctx context.Context, r retriever) (responsePart, *http.Response) {
-	uuid := ctx.Value(uuidKey).(string)
+	uuid := contentUUID(ctx)
 	requestURL := fmt.Sprintf("%s%s", r.uri, uuid)
 	transactionID, _ := transactionidutils.GetTransactionIDFromContext(ctx)
 	req, err := http.NewRequest(http.MethodGet, requestURL, nil)
 	if err != nil {
-		h.handleError(err, r.sourceAppName, requestURL, req.Header.Get(transactionidutils.TransactionIDHeader), uuid)
-		return responsePart{isOk: false, failMsg: fmt.Sprintf("Failed to complete request to %s", r.sourceAppName), statusCode: http.StatusInternalServerError}, nil
+		h.handleError(err, r.sourceAppName, requestURL, transactionID, uuid)
+		return responsePart{isOk: false, failMsg: fmt.Sprintf("Failed to complete request to %s", r.sourceAppName), failure: upstreamRequestProblem, statusCode: http.StatusInternalServerError}, nil
 	}
 	req.Header.Set(transactionidutils.TransactionIDHeader, transactionID)
 	req.Header.Set("Content-Type", "application/json")
 
 	unrollContent, ok := ctx.Value(unrollContentKey).(bool)
-	if ok && r.sourceAppName == h.serviceConfig.content.appName {
+	if ok && h.isContentSource(r.sourceAppName) {
 		q := req.URL.Query()
 		q.Add(unrollContentKey.String(), strconv.FormatBool(unrollContent))
 		req.URL.RawQuery = q.Encode()
@@ -496,7 +663,7 @@ func (h internalContentHandler) callService(ctx context.Context, r retriever) (r
 	//this happens when hostname cannot be resolved or host is not accessible
 	if err != nil {
 		h.handleError(err, r.sourceAppName, req.URL.String(), req.Header.Get(transactionidutils.TransactionIDHeader), uuid)
-		return responsePart{isOk: false, failMsg: fmt.Sprintf("%s is not available", r.sourceAppName), statusCode: http.StatusServiceUnavailable}, nil
+		return responsePart{isOk: false, failMsg: fmt.Sprintf("%s is not available", r.sourceAppName), failure: upstreamUnavailableProblem, statusCode: http.StatusServiceUnavailable}, nil
 	}
 	return h.handleResponse(req, resp, uuid, r.sourceAppName, r.doFail), resp
 }
@@ -508,10 +675,19 @@ func (h internalContentHandler) handleResponse(req *http.Request, resp *http.Res
 		h.log.ResponseEvent(appName, req.URL.String(), resp, uuid)
 		return responsePart{isOk: true, statusCode: http.StatusOK}
 
+	case http.StatusGone:
+		if doFail {
+			h.handleGone(resp, appName, req.URL.String(), uuid)
+			return responsePart{isOk: false, failMsg: fmt.Sprintf("Content was deleted from %s", appName), failure: contentGoneProblem, statusCode: http.StatusGone}
+		}
+		h.log.RequestFailedEvent(appName, req.URL.String(), resp, uuid)
+		h.metrics.recordRequestFailedEvent()
+		return responsePart{isOk: true, statusCode: http.StatusNotFound}
+
 	case http.StatusNotFound:
 		if doFail {
 			h.handleNotFound(resp, appName, req.URL.String(), uuid)
-			return responsePart{isOk: false, failMsg: fmt.Sprintf("Content was not found in %s", appName), statusCode: http.StatusNotFound}
+			return responsePart{isOk: false, failMsg: fmt.Sprintf("Content was not found in %s", appName), failure: contentNotFoundProblem, statusCode: http.StatusNotFound}
 		}
 		h.log.RequestFailedEvent(appName, req.URL.String(), resp, uuid)
 		h.metrics.recordRequestFailedEvent()
@@ -520,7 +696,7 @@ func (h internalContentHandler) handleResponse(req *http.Request, resp *http.Res
 	default:
 		if doFail {
 			h.handleFailedRequest(resp, appName, req.URL.String(), uuid)
-			return responsePart{isOk: false, failMsg: fmt.Sprintf("%s is not available", appName), statusCode: http.StatusServiceUnavailable}
+			return responsePart{isOk: false, failMsg: fmt.Sprintf("%s is not available", appName), failure: upstreamUnavailableProblem, statusCode: http.StatusServiceUnavailable}
 		}
 		h.log.RequestFailedEvent(appName, req.URL.String(), resp, uuid)
 		h.metrics.recordRequestFailedEvent()
@@ -535,12 +711,12 @@ func unmarshallToMap(resp *http.Response) (map[string]interface{}, error) {
 		return content, nil
 	}
 
-	contentBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
 		return content, err
 	}
-	err = json.Unmarshal(contentBytes, &content)
-	return content, err
+	return decodeContent(buf.Bytes())
 }
 
 func extractRequestURL(resp *http.Response) string {
@@ -585,3 +761,8 @@ func (h internalContentHandler) handleNotFound(resp *http.Response, serviceName
 	h.log.RequestFailedEvent(serviceName, url, resp, uuid)
 	h.metrics.recordRequestFailedEvent()
 }
+
+func (h internalContentHandler) handleGone(resp *http.Response, serviceName string, url string, uuid string) {
+	h.log.RequestFailedEvent(serviceName, url, resp, uuid)
+	h.metrics.recordDeletedEvent()
+}
@@ -169,7 +169,8 @@ func TestMergeEmbeds(t *testing.T) {
 	}
 
 	for _, row := range data {
-		res := mergeParts([]responsePart{{content: row.content}, {content: row.component}}, testBaseURL)
+		res, err := mergeParts([]responsePart{{content: row.content}, {content: row.component}}, testBaseURL)
+		assert.NoError(t, err)
 		assert.True(t, reflect.DeepEqual(row.mergedContent, res), "Expected and actual merged content differs.\n Expected: %v\n Actual %v\n", row.mergedContent, res)
 	}
 }
@@ -400,12 +401,37 @@ func TestMergeEmbeddedMapsWithOverlappingFields(t *testing.T) {
 	}
 
 	for _, row := range data {
-		res := mergeParts([]responsePart{{content: row.content}, {content: row.component}}, testBaseURL)
+		res, err := mergeParts([]responsePart{{content: row.content}, {content: row.component}}, testBaseURL)
+		assert.NoError(t, err)
 		assert.True(t, reflect.DeepEqual(row.mergedContent, res), row.name+" - Expected and actual merged content differs.\n Expected: %v\n Actual: %v\n", row.mergedContent, res)
 	}
 
 }
 
+func TestExpandLeadImages(t *testing.T) {
+	h := internalContentHandler{serviceConfig: &serviceConfig{envAPIHost: "api.ft.com"}}
+	content := map[string]interface{}{
+		"leadImages": []interface{}{
+			map[string]interface{}{"id": "http://www.ft.com/thing/1", "type": "square", "image": map[string]interface{}{"id": "http://www.ft.com/thing/1", "requestUrl": "http://api.ft.com/content/2", "title": "Image"}},
+			map[string]interface{}{"id": "3", "image": map[string]interface{}{"title": "Image"}},
+			map[string]interface{}{"id": "4"},
+		},
+	}
+
+	expanded, err := h.expandLeadImages(content)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "https://api.ft.com/content/1", "type": "square", "image": map[string]interface{}{"id": "http://api.ft.com/content/2", "apiUrl": "http://api.ft.com/content/2", "title": "Image"}},
+		map[string]interface{}{"id": "https://api.ft.com/content/3", "image": map[string]interface{}{"id": "https://api.ft.com/content/3", "apiUrl": "https://api.ft.com/content/3", "title": "Image"}},
+		map[string]interface{}{"id": "https://api.ft.com/content/4"},
+	}, expanded["leadImages"])
+
+	_, err = h.expandLeadImages(map[string]interface{}{"leadImages": []interface{}{map[string]interface{}{"id": 1.0}}})
+	assert.EqualError(t, err, "leadImages 0 id should be a string, got float64")
+	_, err = h.expandLeadImages(map[string]interface{}{})
+	assert.Error(t, err)
+}
+
 func TestFilterKeys(t *testing.T) {
 	data := []struct {
 		name            string
@@ -500,7 +526,8 @@ func TestResolvingOverlappingMergesFullContent(t *testing.T) {
 	err = json.Unmarshal([]byte(internalComponentJSON), &internalComponent)
 	assert.Equal(t, nil, err, "Error %v", err)
 
-	results := mergeParts([]responsePart{{content: content}, {content: internalComponent}}, "")
+	results, err := mergeParts([]responsePart{{content: content}, {content: internalComponent}}, "")
+	assert.NoError(t, err)
 
 	promotionalTitle := results["alternativeTitles"].(map[string]interface{})["promotionalTitle"]
 	shortTeaser := results["alternativeTitles"].(map[string]interface{})["shortTeaser"]
@@ -511,6 +538,35 @@ func TestResolvingOverlappingMergesFullContent(t *testing.T) {
 	assert.Equal(t, "standfirst", alternativeStandfirsts)
 }
 
+func TestCanonicalUUID(t *testing.T) {
+	data := []struct {
+		identifier string
+		canonical  string
+		valid      bool
+	}{
+		{"5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", true},
+		{"5C3CAE78-DBEF-11E6-9D7C-BE108F1C1DCE", "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", true},
+		{"{5c3cae78-dbef-11e6-9d7c-be108f1c1dce}", "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", true},
+		{"urn:uuid:5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", true},
+		{"http://www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", true},
+		{"http:/www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", true},
+		{"https://api.ft.com/content/5C3CAE78-DBEF-11E6-9D7C-BE108F1C1DCE", "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", true},
+		{"foo/bar/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "", false},
+		{"5c3cae78-dbef-11e6-9d7c-be108f1c1dce/f3add2e0-dbfa-11e6-a7d5-ce30ecef69c7", "", false},
+		{"http://www.example.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "", false},
+		{"http://www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce/extra", "", false},
+		{" 5c3cae78-dbef-11e6-9d7c-be108f1c1dce ", "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", true},
+		{"123-invalid-uuid", "", false},
+		{"http://www.ft.com/thing/", "", false},
+	}
+
+	for _, row := range data {
+		canonical, err := canonicalUUID(row.identifier)
+		assert.Equal(t, row.canonical, canonical, row.identifier)
+		assert.Equal(t, row.valid, err == nil, row.identifier)
+	}
+}
+
 func AreEqualJSON(s1, s2 string) (bool, error) {
 	var o1 interface{}
 	var o2 interface{}
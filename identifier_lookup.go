@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+)
+
+const (
+	identifierAuthorityParam = "identifierAuthority"
+	identifierValueParam     = "identifierValue"
+	webURLParam              = "webUrl"
+	redirectParam            = "redirect"
+)
+
+var errIdentifierNotFound = errors.New("no content found for the identifier")
+
+// ServeByIdentifier resolves the content uuid of an alternate identifier or web URL through the identifier resolver,
+// then either serves the content or redirects to its canonical uuid URL.
+func (h internalContentHandler) ServeByIdentifier(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	tid := transactionidutils.GetTransactionIDFromRequest(r)
+	query := lookupQuery(r.URL.Query())
+	if query == nil {
+		writeProblem(w, newProblem(missingIdentifierProblem, http.StatusBadRequest, fmt.Sprintf("Either %s and %s or %s should be provided", identifierAuthorityParam, identifierValueParam, webURLParam), tid))
+		return
+	}
+	if h.serviceConfig.identifierResolver.appURI == "" {
+		writeProblem(w, newProblem(lookupNotConfiguredProblem, http.StatusNotImplemented, "Lookup by identifier is not configured", tid))
+		return
+	}
+
+	uuid, err := h.resolveIdentifier(query, tid)
+	if errors.Is(err, errIdentifierNotFound) {
+		writeProblem(w, newProblem(identifierNotFoundProblem, http.StatusNotFound, "Content was not found for the given identifier", tid).withUpstream(h.serviceConfig.identifierResolver.appName, 0))
+		return
+	}
+	if err != nil {
+		h.handleError(err, h.serviceConfig.identifierResolver.appName, h.serviceConfig.identifierResolver.appURI, tid, "")
+		writeProblem(w, newProblem(identifierResolutionProblem, http.StatusServiceUnavailable, fmt.Sprintf("%s is not available", h.serviceConfig.identifierResolver.appName), tid).withUpstream(h.serviceConfig.identifierResolver.appName, 0))
+		return
+	}
+
+	// the content an identifier resolves to can change, so the redirect is not permanent
+	if parseBoolParam(r, redirectParam) {
+		http.Redirect(w, r, canonicalContentPath(h.serviceConfig.handlerPath, uuid, r.URL.Query()), http.StatusFound)
+		return
+	}
+	h.serveContent(w, r, uuid)
+}
+
+func lookupQuery(params url.Values) url.Values {
+	query := url.Values{}
+	if authority, value := params.Get(identifierAuthorityParam), params.Get(identifierValueParam); authority != "" && value != "" {
+		query.Set(identifierAuthorityParam, authority)
+		query.Set(identifierValueParam, value)
+		return query
+	}
+	if webURL := params.Get(webURLParam); webURL != "" {
+		query.Set(webURLParam, webURL)
+		return query
+	}
+	return nil
+}
+
+// canonicalContentPath returns the path of the content endpoint for the uuid, keeping the other request parameters.
+func canonicalContentPath(handlerPath string, uuid string, params url.Values) string {
+	query := url.Values{}
+	for key, values := range params {
+		switch key {
+		case identifierAuthorityParam, identifierValueParam, webURLParam, redirectParam:
+			continue
+		}
+		query[key] = values
+	}
+	path := "/" + handlerPath + "/" + uuid
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path
+}
+
+// resolveIdentifier asks the identifier resolver for the content uuid. The resolver either redirects to the content
+// or responds with a JSON object holding its uuid or id.
+func (h internalContentHandler) resolveIdentifier(query url.Values, tid string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, h.serviceConfig.identifierResolver.appURI+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(transactionidutils.TransactionIDHeader, tid)
+
+	client := *h.serviceConfig.httpClient
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer cleanupResp(resp, h.log.log)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", errIdentifierNotFound
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		location, err := resp.Location()
+		if err != nil {
+			return "", err
+		}
+		return validResolvedUUID(extractIDValue(location.Path))
+	case resp.StatusCode == http.StatusOK:
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return "", err
+		}
+		var resolved map[string]interface{}
+		if err = json.Unmarshal(body, &resolved); err != nil {
+			return "", err
+		}
+		for _, key := range []string{"uuid", "id"} {
+			if id, ok := resolved[key].(string); ok {
+				return validResolvedUUID(extractIDValue(id))
+			}
+		}
+		return "", errIdentifierNotFound
+	default:
+		return "", fmt.Errorf("received status code %d from %s", resp.StatusCode, h.serviceConfig.identifierResolver.appName)
+	}
+}
+
+// validResolvedUUID returns the canonical form of the uuid returned by the resolver, which can be upper case.
+func validResolvedUUID(uuid string) (string, error) {
+	canonical, err := canonicalUUID(uuid)
+	if err != nil {
+		return "", fmt.Errorf("resolved an invalid uuid: %w", err)
+	}
+	return canonical, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveIdentifier(t *testing.T) {
+	data := []struct {
+		name         string
+		handler      http.HandlerFunc
+		expectedUUID string
+		expectedErr  bool
+	}{
+		{
+			"redirect to content",
+			func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/content/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", http.StatusMovedPermanently)
+			},
+			"5c3cae78-dbef-11e6-9d7c-be108f1c1dce",
+			false,
+		},
+		{
+			"json with uuid",
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"uuid": "5c3cae78-dbef-11e6-9d7c-be108f1c1dce"}`))
+			},
+			"5c3cae78-dbef-11e6-9d7c-be108f1c1dce",
+			false,
+		},
+		{
+			"json with id",
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"id": "http://www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce"}`))
+			},
+			"5c3cae78-dbef-11e6-9d7c-be108f1c1dce",
+			false,
+		},
+		{
+			"json with upper case uuid",
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"uuid": "5C3CAE78-DBEF-11E6-9D7C-BE108F1C1DCE"}`))
+			},
+			"5c3cae78-dbef-11e6-9d7c-be108f1c1dce",
+			false,
+		},
+		{
+			"invalid uuid",
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"uuid": "not-a-uuid"}`))
+			},
+			"",
+			true,
+		},
+		{
+			"resolver failure",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			"",
+			true,
+		},
+	}
+
+	for _, row := range data {
+		resolver := httptest.NewServer(row.handler)
+		h := internalContentHandler{
+			serviceConfig: &serviceConfig{
+				identifierResolver: externalService{appName: "resolver", appURI: resolver.URL},
+				httpClient:         http.DefaultClient,
+			},
+			log: newAppLogger(),
+		}
+
+		uuid, err := h.resolveIdentifier(url.Values{"webUrl": {"http://www.ft.com/content/1"}}, "tid_test")
+
+		assert.Equal(t, row.expectedUUID, uuid, row.name)
+		assert.Equal(t, row.expectedErr, err != nil, row.name)
+		resolver.Close()
+	}
+}
+
+func TestCanonicalContentPath(t *testing.T) {
+	params := url.Values{
+		"identifierAuthority": {"http://api.ft.com/system/FTCOM-METHODE"},
+		"identifierValue":     {"5c3cae78-dbef-11e6-9d7c-be108f1c1dce"},
+		"redirect":            {"true"},
+		"unrollContent":       {"true"},
+	}
+	assert.Equal(t, "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce?unrollContent=true", canonicalContentPath("internalcontent", "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", params))
+	assert.Equal(t, "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", canonicalContentPath("internalcontent", "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", url.Values{}))
+}
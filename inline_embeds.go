@@ -0,0 +1,100 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var ftContentStartTag = regexp.MustCompile(`<ft-content\b([^>]*?)(/?)>`)
+
+var ftContentURLAttr = regexp.MustCompile(`\burl="([^"]*)"`)
+
+// inlineEmbeds injects the resolved data of the merged embeds as attributes of the matching <ft-content> tags in bodyXML,
+// so that renderers do not need to cross-reference the embeds array.
+func inlineEmbeds(content map[string]interface{}) {
+	bodyXML, ok := contentString(content, "bodyXML")
+	if !ok || bodyXML == "" {
+		return
+	}
+	embeds, ok := content["embeds"].([]interface{})
+	if !ok || len(embeds) == 0 {
+		return
+	}
+
+	embedsByID := make(map[string]map[string]interface{})
+	for _, e := range embeds {
+		embed, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, ok := embed["id"].(string)
+		if !ok {
+			continue
+		}
+		embedsByID[extractIDValue(id)] = embed
+	}
+
+	content["bodyXML"] = ftContentStartTag.ReplaceAllStringFunc(bodyXML, func(tag string) string {
+		groups := ftContentStartTag.FindStringSubmatch(tag)
+		attrs, selfClosing := groups[1], groups[2]
+		url := ftContentURLAttr.FindStringSubmatch(attrs)
+		if url == nil {
+			return tag
+		}
+		embed, found := embedsByID[extractIDValue(url[1])]
+		if !found {
+			return tag
+		}
+		return "<ft-content" + attrs + embedAttributes(embed) + selfClosing + ">"
+	})
+}
+
+func embedAttributes(embed map[string]interface{}) string {
+	var sb strings.Builder
+	if title := embedTitle(embed); title != "" {
+		writeAttribute(&sb, "data-title", title)
+	}
+	if embedType, ok := embed["type"].(string); ok && embedType != "" {
+		writeAttribute(&sb, "data-embed-type", embedType)
+	}
+	if imageURLs := embedImageURLs(embed); len(imageURLs) > 0 {
+		writeAttribute(&sb, "data-image-urls", strings.Join(imageURLs, " "))
+	}
+	return sb.String()
+}
+
+func writeAttribute(sb *strings.Builder, name string, value string) {
+	sb.WriteString(" ")
+	sb.WriteString(name)
+	sb.WriteString(`="`)
+	sb.WriteString(html.EscapeString(value))
+	sb.WriteString(`"`)
+}
+
+func embedTitle(embed map[string]interface{}) string {
+	for _, key := range []string{"title", "displayTitle", "description"} {
+		if title, ok := embed[key].(string); ok && title != "" {
+			return title
+		}
+	}
+	return ""
+}
+
+func embedImageURLs(embed map[string]interface{}) []string {
+	var imageURLs []string
+	if binaryURL, ok := embed["binaryUrl"].(string); ok && binaryURL != "" {
+		imageURLs = append(imageURLs, binaryURL)
+	}
+	members, _ := embed["members"].([]interface{})
+	for _, m := range members {
+		member, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if binaryURL, ok := member["binaryUrl"].(string); ok && binaryURL != "" {
+			imageURLs = append(imageURLs, binaryURL)
+		}
+	}
+	return imageURLs
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInlineEmbeds(t *testing.T) {
+	data := []struct {
+		name            string
+		content         map[string]interface{}
+		expectedBodyXML string
+	}{
+		{
+			"image set is inlined",
+			map[string]interface{}{
+				"bodyXML": `<body><ft-content type="http://www.ft.com/ontology/content/ImageSet" url="http://api.ft.com/content/1"></ft-content><p>text</p></body>`,
+				"embeds": []interface{}{
+					map[string]interface{}{
+						"id":    testBaseURL + "1",
+						"type":  "http://www.ft.com/ontology/content/ImageSet",
+						"title": "A & B",
+						"members": []interface{}{
+							map[string]interface{}{"binaryUrl": "http://img/1-mobile.png"},
+							map[string]interface{}{"binaryUrl": "http://img/1-wide.png"},
+						},
+					},
+				},
+			},
+			`<body><ft-content type="http://www.ft.com/ontology/content/ImageSet" url="http://api.ft.com/content/1" data-title="A &amp; B" data-embed-type="http://www.ft.com/ontology/content/ImageSet" data-image-urls="http://img/1-mobile.png http://img/1-wide.png"></ft-content><p>text</p></body>`,
+		},
+		{
+			"self closing tag is inlined",
+			map[string]interface{}{
+				"bodyXML": `<body><ft-content url="http://api.ft.com/content/2"/></body>`,
+				"embeds": []interface{}{
+					map[string]interface{}{
+						"id":           testBaseURL + "2",
+						"displayTitle": "Clip",
+					},
+				},
+			},
+			`<body><ft-content url="http://api.ft.com/content/2" data-title="Clip"/></body>`,
+		},
+		{
+			"unknown embed is left untouched",
+			map[string]interface{}{
+				"bodyXML": `<body><ft-content url="http://api.ft.com/content/3"></ft-content></body>`,
+				"embeds": []interface{}{
+					map[string]interface{}{
+						"id":    testBaseURL + "1",
+						"title": "Other",
+					},
+				},
+			},
+			`<body><ft-content url="http://api.ft.com/content/3"></ft-content></body>`,
+		},
+		{
+			"no embeds leaves body untouched",
+			map[string]interface{}{
+				"bodyXML": `<body><ft-content url="http://api.ft.com/content/1"></ft-content></body>`,
+			},
+			`<body><ft-content url="http://api.ft.com/content/1"></ft-content></body>`,
+		},
+	}
+
+	for _, row := range data {
+		inlineEmbeds(row.content)
+		assert.Equal(t, row.expectedBodyXML, row.content["bodyXML"], row.name)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const bodyFormatMarkdown = "markdown"
+
+// markdownEscaper escapes the Markdown metacharacters of the text of the body, so that it is not taken for markup.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	">", `\>`,
+	"#", `\#`,
+	"|", `\|`,
+)
+
+// listMarker matches the text which would start a list when it starts a block.
+var listMarker = regexp.MustCompile(`^([-+]|\d+[.)])( |$)`)
+
+type markdownConverter struct {
+	embedsByID map[string]map[string]interface{}
+}
+
+// bodyXMLToMarkdown converts the given bodyXML to Markdown. The embeds are used to resolve the titles and images of
+// the <ft-content> tags; without them the tags are rendered as plain links to the embedded content.
+func bodyXMLToMarkdown(bodyXML string, embeds []interface{}) string {
+	c := markdownConverter{make(map[string]map[string]interface{})}
+	for _, e := range embeds {
+		embed, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, ok := embed["id"].(string); ok {
+			c.embedsByID[extractIDValue(id)] = embed
+		}
+	}
+	blocks := c.blocks(parseBodyTree(bodyXML).children)
+	return strings.Join(blocks, "\n\n")
+}
+
+func (c markdownConverter) blocks(nodes []*bodyNode) []string {
+	var blocks []string
+	var inline strings.Builder
+	flush := func() {
+		if text := strings.TrimSpace(inline.String()); text != "" {
+			blocks = append(blocks, escapeBlockStart(text))
+		}
+		inline.Reset()
+	}
+	for _, n := range nodes {
+		if block, isBlock := c.block(n); isBlock {
+			flush()
+			if block != "" {
+				blocks = append(blocks, block)
+			}
+			continue
+		}
+		inline.WriteString(c.inline(n))
+	}
+	flush()
+	return blocks
+}
+
+func (c markdownConverter) block(n *bodyNode) (string, bool) {
+	switch n.name {
+	case "body", "div":
+		return strings.Join(c.blocks(n.children), "\n\n"), true
+	case "p":
+		return escapeBlockStart(c.inlineChildren(n)), true
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level, _ := strconv.Atoi(n.name[1:])
+		return strings.Repeat("#", level) + " " + c.inlineChildren(n), true
+	case "ul", "ol":
+		return c.list(n), true
+	case "blockquote":
+		return quote(strings.Join(c.blocks(n.children), "\n\n")), true
+	case "pull-quote":
+		return c.pullQuote(n), true
+	case "ft-content":
+		return c.embed(n), true
+	}
+	return "", false
+}
+
+func (c markdownConverter) inline(n *bodyNode) string {
+	switch n.name {
+	case "":
+		return markdownEscaper.Replace(collapseInlineWhitespace(n.text))
+	case "a":
+		text := c.inlineChildren(n)
+		if href, ok := n.attrs["href"]; ok && href != "" {
+			return "[" + text + "](" + href + ")"
+		}
+		return text
+	case "em", "i":
+		return wrapInline(c.inlineChildren(n), "*")
+	case "strong", "b":
+		return wrapInline(c.inlineChildren(n), "**")
+	case "br":
+		return "  \n"
+	}
+	return c.inlineChildren(n)
+}
+
+func (c markdownConverter) inlineChildren(n *bodyNode) string {
+	var sb strings.Builder
+	for _, child := range n.children {
+		sb.WriteString(c.inline(child))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func (c markdownConverter) list(n *bodyNode) string {
+	var items []string
+	for _, child := range n.children {
+		if child.name != "li" {
+			continue
+		}
+		marker := "- "
+		if n.name == "ol" {
+			marker = strconv.Itoa(len(items)+1) + ". "
+		}
+		item := strings.Join(c.blocks(child.children), "\n\n")
+		items = append(items, marker+strings.ReplaceAll(item, "\n", "\n"+strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+func (c markdownConverter) pullQuote(n *bodyNode) string {
+	var text, source string
+	for _, child := range n.children {
+		switch child.name {
+		case "pull-quote-text":
+			text = strings.Join(c.blocks(child.children), "\n\n")
+		case "pull-quote-source":
+			source = c.inlineChildren(child)
+		}
+	}
+	if text == "" {
+		text = strings.Join(c.blocks(n.children), "\n\n")
+	}
+	if source != "" {
+		text += "\n\n— " + source
+	}
+	return quote(text)
+}
+
+func (c markdownConverter) embed(n *bodyNode) string {
+	url := n.attrs["url"]
+	if url == "" {
+		return ""
+	}
+	embed := c.embedsByID[extractIDValue(url)]
+	title := markdownEscaper.Replace(embedTitle(embed))
+	if imageURLs := embedImageURLs(embed); len(imageURLs) > 0 {
+		return "![" + title + "](" + imageURLs[len(imageURLs)-1] + ")"
+	}
+	if strings.HasSuffix(n.attrs["type"], "/ImageSet") || strings.HasSuffix(n.attrs["type"], "/Image") {
+		return "![" + title + "](" + url + ")"
+	}
+	if title == "" {
+		title = markdownEscaper.Replace(url)
+	}
+	return "[" + title + "](" + url + ")"
+}
+
+// escapeBlockStart escapes the list marker the text of a block starts with, such as "1984." or "-".
+func escapeBlockStart(text string) string {
+	if loc := listMarker.FindStringSubmatchIndex(text); loc != nil {
+		marker := loc[3]
+		return text[:marker-1] + `\` + text[marker-1:]
+	}
+	return text
+}
+
+func quote(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func wrapInline(text string, marker string) string {
+	if text == "" {
+		return ""
+	}
+	return marker + text + marker
+}
+
+func collapseInlineWhitespace(s string) string {
+	collapsed := collapseWhitespace(s)
+	if collapsed == "" {
+		if s != "" {
+			return " "
+		}
+		return ""
+	}
+	if strings.TrimLeft(s, " \t\r\n") != s {
+		collapsed = " " + collapsed
+	}
+	if strings.TrimRight(s, " \t\r\n") != s {
+		collapsed += " "
+	}
+	return collapsed
+}
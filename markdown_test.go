@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyXMLToMarkdown(t *testing.T) {
+	data := []struct {
+		name     string
+		bodyXML  string
+		embeds   []interface{}
+		markdown string
+	}{
+		{
+			"paragraphs and headings",
+			"<body><h2>Heading</h2>\n<p>First <em>para</em>.</p>\n<p>Second <strong>para</strong>\n with <a href=\"https://www.ft.com\">a link</a>.</p></body>",
+			nil,
+			"## Heading\n\nFirst *para*.\n\nSecond **para** with [a link](https://www.ft.com).",
+		},
+		{
+			"lists",
+			"<body><ul><li>one</li><li>two</li></ul><ol><li>first</li><li>second</li></ol></body>",
+			nil,
+			"- one\n- two\n\n1. first\n2. second",
+		},
+		{
+			"blockquote",
+			"<body><blockquote><p>Line one</p><p>Line two</p></blockquote></body>",
+			nil,
+			"> Line one\n>\n> Line two",
+		},
+		{
+			"pull quote",
+			"<body><pull-quote><pull-quote-text><p>Quoted</p></pull-quote-text><pull-quote-source>Someone</pull-quote-source></pull-quote></body>",
+			nil,
+			"> Quoted\n>\n> — Someone",
+		},
+		{
+			"resolved image set embed",
+			"<body><ft-content type=\"http://www.ft.com/ontology/content/ImageSet\" url=\"http://api.ft.com/content/1\"></ft-content></body>",
+			[]interface{}{
+				map[string]interface{}{
+					"id":          testBaseURL + "1",
+					"description": "Chart",
+					"members": []interface{}{
+						map[string]interface{}{"binaryUrl": "http://img/1-mobile.png"},
+						map[string]interface{}{"binaryUrl": "http://img/1-wide.png"},
+					},
+				},
+			},
+			"![Chart](http://img/1-wide.png)",
+		},
+		{
+			"unresolved embeds",
+			"<body><ft-content type=\"http://www.ft.com/ontology/content/ImageSet\" url=\"http://api.ft.com/content/1\"></ft-content><ft-content type=\"http://www.ft.com/ontology/content/Article\" url=\"http://api.ft.com/content/2\"></ft-content></body>",
+			nil,
+			"![](http://api.ft.com/content/1)\n\n[http://api.ft.com/content/2](http://api.ft.com/content/2)",
+		},
+		{
+			"escaped text",
+			"<body><p>a_b_c [x](y) and 5 * 3 &lt;b&gt; #1 `code`</p><p><a href=\"https://www.ft.com\">see *this*</a></p><p>1984. The year</p><p>- not a list</p></body>",
+			nil,
+			"a\\_b\\_c \\[x\\](y) and 5 \\* 3 \\<b\\> \\#1 \\`code\\`\n\n[see \\*this\\*](https://www.ft.com)\n\n1984\\. The year\n\n\\- not a list",
+		},
+		{
+			"escaped embed title",
+			"<body><ft-content type=\"http://www.ft.com/ontology/content/Article\" url=\"http://api.ft.com/content/1\"></ft-content></body>",
+			[]interface{}{map[string]interface{}{"id": testBaseURL + "1", "title": "The [best] *ever*"}},
+			"[The \\[best\\] \\*ever\\*](http://api.ft.com/content/1)",
+		},
+	}
+
+	for _, row := range data {
+		assert.Equal(t, row.markdown, bodyXMLToMarkdown(row.bodyXML, row.embeds), row.name)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+var benchmarkFixtures = []struct {
+	name               string
+	content            string
+	internalComponents string
+}{
+	{"article", "test-resources/enriched-content-api-output.json", "test-resources/content-public-read-output.json"},
+	{"unrolled", "test-resources/enriched-content-api-output-unrollContent.json", "test-resources/content-public-read-output-unrollContent.json"},
+}
+
+func readBenchmarkFixture(b *testing.B, path string) []byte {
+	b.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+func BenchmarkDecodeContent(b *testing.B) {
+	for _, f := range benchmarkFixtures {
+		data := readBenchmarkFixture(b, f.content)
+		b.Run(f.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := decodeContent(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkMergeAndRender decodes, merges and renders the content and the internal components as a request does.
+func BenchmarkMergeAndRender(b *testing.B) {
+	for _, f := range benchmarkFixtures {
+		contentData := readBenchmarkFixture(b, f.content)
+		internalComponentsData := readBenchmarkFixture(b, f.internalComponents)
+		b.Run(f.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				content, err := decodeContent(contentData)
+				if err != nil {
+					b.Fatal(err)
+				}
+				internalComponents, err := decodeContent(internalComponentsData)
+				if err != nil {
+					b.Fatal(err)
+				}
+				merged, err := mergeParts([]responsePart{{content: content}, {content: internalComponents}}, testBaseURL)
+				if err != nil {
+					b.Fatal(err)
+				}
+				merged = removeEmptyMapFields(merged)
+				if _, err := renderJSON(merged); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+// FuzzMergeParts checks that merging malformed upstream payloads fails with an error instead of panicking.
+// The seed corpus is made of the test-resources fixtures and of the malformed payloads in testdata/fuzz.
+func FuzzMergeParts(f *testing.F) {
+	for _, pair := range [][2]string{
+		{"test-resources/enriched-content-api-output.json", "test-resources/content-public-read-output.json"},
+		{"test-resources/embedded-enrichedcontent-output.json", "test-resources/embedded-internalcomponents-output.json"},
+	} {
+		content, err := ioutil.ReadFile(pair[0])
+		if err != nil {
+			f.Fatal(err)
+		}
+		internalComponents, err := ioutil.ReadFile(pair[1])
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(content, internalComponents)
+	}
+
+	f.Fuzz(func(t *testing.T, contentJSON []byte, internalComponentsJSON []byte) {
+		content, err := decodeContent(contentJSON)
+		if err != nil {
+			t.Skip()
+		}
+		internalComponents, err := decodeContent(internalComponentsJSON)
+		if err != nil {
+			t.Skip()
+		}
+		merged, err := mergeParts([]responsePart{{content: content}, {content: internalComponents}}, testBaseURL)
+		if err != nil {
+			return
+		}
+		merged = removeEmptyMapFields(merged)
+		if _, err := json.Marshal(merged); err != nil {
+			t.Fatalf("merged content cannot be encoded: %v", err)
+		}
+	})
+}
+
+// FuzzMergeEmbeds checks that merging malformed embeds, nested ones included, fails with an error instead of panicking.
+func FuzzMergeEmbeds(f *testing.F) {
+	f.Add([]byte(`[{"id": "http://www.ft.com/thing/1", "requestUrl": "http://api.ft.com/content/1"}, {"uuid": "2"}]`))
+	f.Add([]byte(`[{"id": 1}, {"uuid": null}, "embed"]`))
+	f.Add([]byte(`[{"id": "1", "embeds": [{"uuid": "2", "requestUrl": "http://api.ft.com/content/2"}, {"title": "No id"}]}]`))
+
+	f.Fuzz(func(t *testing.T, embedsJSON []byte) {
+		var value interface{}
+		if json.Unmarshal(embedsJSON, &value) != nil {
+			t.Skip()
+		}
+		a, err := newContentEmbeds(value)
+		if err != nil {
+			return
+		}
+		b, _ := newContentEmbeds(value)
+		mergeEmbeds(a, b, testBaseURL)
+	})
+}
@@ -11,13 +11,17 @@ type Metrics struct {
 	errorMeter         string
 	requestFailedMeter string
 	responseMeter      string
+	deletedMeter       string
+	inconsistentMeter  string
 }
 
 func NewMetrics() Metrics {
-	mx := Metrics{metrics.DefaultRegistry, "5xx", "4xx", "200"}
+	mx := Metrics{metrics.DefaultRegistry, "5xx", "4xx", "200", "410", "inconsistent"}
 	mx.registry.Register(mx.errorMeter, metrics.NewMeter())
 	mx.registry.Register(mx.requestFailedMeter, metrics.NewMeter())
 	mx.registry.Register(mx.responseMeter, metrics.NewMeter())
+	mx.registry.Register(mx.deletedMeter, metrics.NewMeter())
+	mx.registry.Register(mx.inconsistentMeter, metrics.NewMeter())
 	return mx
 }
 
@@ -36,6 +40,16 @@ func (m Metrics) recordResponseEvent() {
 	meter.Mark(1)
 }
 
+func (m Metrics) recordDeletedEvent() {
+	meter := m.registry.Get(m.deletedMeter).(metrics.Meter)
+	meter.Mark(1)
+}
+
+func (m Metrics) recordInconsistentEvent() {
+	meter := m.registry.Get(m.inconsistentMeter).(metrics.Meter)
+	meter.Mark(1)
+}
+
 func metricsHTTPEndpoint(w http.ResponseWriter, r *http.Request) {
 	metrics.WriteOnce(metrics.DefaultRegistry, w)
 }
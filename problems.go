@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const problemTypeBaseURI = "https://api.ft.com/internal-content-api/problems/"
+
+type problemType struct {
+	name  string
+	title string
+}
+
+var (
+	invalidUUIDProblem          = problemType{"invalid-uuid", "Invalid content uuid"}
+	internalErrorProblem        = problemType{"internal-error", "Internal server error"}
+	invalidParameterProblem     = problemType{"invalid-parameter", "Invalid query parameter"}
+	missingIdentifierProblem    = problemType{"missing-identifier", "Missing content identifier"}
+	contentNotFoundProblem      = problemType{"content-not-found", "Content not found"}
+	contentGoneProblem          = problemType{"content-gone", "Content was deleted"}
+	embargoedProblem            = problemType{"content-embargoed", "Content under embargo"}
+	upstreamUnavailableProblem  = problemType{"upstream-unavailable", "Upstream service not available"}
+	upstreamRequestProblem      = problemType{"upstream-request-failed", "Upstream request could not be created"}
+	invalidUpstreamProblem      = problemType{"invalid-upstream-response", "Invalid upstream response"}
+	notSyndicatableProblem      = problemType{"not-syndicatable", "Content cannot be syndicated"}
+	renderingProblem            = problemType{"rendering-failed", "Content could not be rendered"}
+	lookupNotConfiguredProblem  = problemType{"lookup-not-configured", "Lookup by identifier not configured"}
+	identifierNotFoundProblem   = problemType{"identifier-not-found", "No content found for the identifier"}
+	identifierResolutionProblem = problemType{"identifier-resolution-failed", "Identifier could not be resolved"}
+)
+
+// Problem is an RFC 7807 problem details error response, extended with the transaction id and, when an upstream
+// service caused the error, its name and status code.
+type Problem struct {
+	Type           string `json:"type"`
+	Title          string `json:"title"`
+	Status         int    `json:"status"`
+	Detail         string `json:"detail,omitempty"`
+	TransactionID  string `json:"transactionId,omitempty"`
+	UUID           string `json:"uuid,omitempty"`
+	Upstream       string `json:"upstream,omitempty"`
+	UpstreamStatus int    `json:"upstreamStatus,omitempty"`
+}
+
+func newProblem(pt problemType, status int, detail string, transactionID string) Problem {
+	return Problem{
+		Type:          problemTypeBaseURI + pt.name,
+		Title:         pt.title,
+		Status:        status,
+		Detail:        detail,
+		TransactionID: transactionID,
+	}
+}
+
+func (p Problem) withUpstream(upstream string, upstreamStatus int) Problem {
+	p.Upstream = upstream
+	p.UpstreamStatus = upstreamStatus
+	return p
+}
+
+func writeProblem(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+	w.WriteHeader(p.Status)
+	if msg, err := json.Marshal(p); err == nil {
+		_, _ = w.Write(msg)
+	}
+}
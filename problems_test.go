@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteProblem(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeProblem(w, newProblem(contentNotFoundProblem, http.StatusNotFound, "Content was not found in source", "tid_test").withUpstream("source", http.StatusNotFound))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/problem+json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, map[string]interface{}{
+		"type":           problemTypeBaseURI + "content-not-found",
+		"title":          "Content not found",
+		"status":         float64(http.StatusNotFound),
+		"detail":         "Content was not found in source",
+		"transactionId":  "tid_test",
+		"upstream":       "source",
+		"upstreamStatus": float64(http.StatusNotFound),
+	}, body)
+}
+
+func TestWriteProblemOmitsEmptyExtensions(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeProblem(w, newProblem(lookupNotConfiguredProblem, http.StatusNotImplemented, "", ""))
+
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body, 3)
+	assert.Equal(t, "Lookup by identifier not configured", body["title"])
+}
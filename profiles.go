@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+const profileKey contextKey = "profile"
+
+// responseProfile bundles the settings of a consumer: the projection of the fields, the unrolling of the content,
+// the empty field policy and the output format. The parameters of a request override the ones of its profile.
+type responseProfile struct {
+	// Fields holds the paths of the fields of the response, all of them when empty
+	Fields          []string `json:"fields"`
+	UnrollContent   *bool    `json:"unrollContent"`
+	KeepEmpty       []string `json:"keepEmpty"`
+	DropEmptyArrays *bool    `json:"dropEmptyArrays"`
+	// Format is the media type of the response, replacing the negotiation of the Accept header
+	Format string `json:"format"`
+	// APIKeys are the values of the profile header selecting the profile
+	APIKeys []string `json:"apiKeys"`
+	// projection is the filterKeys form of Fields
+	projection map[string]interface{}
+}
+
+// parseResponseProfiles parses the profiles, given as a JSON object keyed by the profile names.
+func parseResponseProfiles(profiles string) (map[string]responseProfile, error) {
+	var parsed map[string]responseProfile
+	if profiles == "" {
+		return parsed, nil
+	}
+	if err := json.Unmarshal([]byte(profiles), &parsed); err != nil {
+		return nil, err
+	}
+	profilesByKey := make(map[string]string)
+	for name, p := range parsed {
+		if p.Format != "" {
+			if _, found := rendererFor(p.Format); !found {
+				return nil, fmt.Errorf("unsupported format %q of the %s profile", p.Format, name)
+			}
+		}
+		if len(p.Fields) > 0 {
+			projection, err := fieldPathsFilter(p.Fields)
+			if err != nil {
+				return nil, fmt.Errorf("invalid fields of the %s profile: %v", name, err)
+			}
+			p.projection = projection
+		}
+		for _, key := range p.APIKeys {
+			if other, found := profilesByKey[key]; found {
+				return nil, fmt.Errorf("the %s and %s profiles are selected by the same API key", other, name)
+			}
+			profilesByKey[key] = name
+		}
+		parsed[name] = p
+	}
+	return parsed, nil
+}
+
+// profileNames lists the names of the profiles, leaving out their API keys.
+func profileNames(profiles map[string]responseProfile) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// requestProfile returns the profile named by the profile parameter of the request or, without it,
+// the profile selected by the value of the profile header. The zero profile is returned when none is selected.
+func (h internalContentHandler) requestProfile(r *http.Request) (responseProfile, error) {
+	if name := r.URL.Query().Get(profileKey.String()); name != "" {
+		p, found := h.serviceConfig.responseProfiles[name]
+		if !found {
+			return responseProfile{}, fmt.Errorf("unknown profile %q", name)
+		}
+		return p, nil
+	}
+	if h.serviceConfig.profileHeader == "" {
+		return responseProfile{}, nil
+	}
+	if key := r.Header.Get(h.serviceConfig.profileHeader); key != "" {
+		for _, p := range h.serviceConfig.responseProfiles {
+			for _, k := range p.APIKeys {
+				if k == key {
+					return p, nil
+				}
+			}
+		}
+	}
+	return responseProfile{}, nil
+}
+
+// unrollContent tells whether the content is unrolled, the unrollContent parameter overriding the profile.
+func (p responseProfile) unrollContent(r *http.Request) bool {
+	if _, given := r.URL.Query()[unrollContentKey.String()]; given || p.UnrollContent == nil {
+		return parseBoolParam(r, unrollContentKey)
+	}
+	return *p.UnrollContent
+}
+
+// renderer returns the renderer of the profile format, negotiating it from the Accept header without one.
+func (p responseProfile) renderer(r *http.Request) renderer {
+	if rr, found := rendererFor(p.Format); found {
+		return rr
+	}
+	return negotiateRenderer(r.Header.Get("Accept"))
+}
+
+// project returns the fields of the content selected by the profile, the whole content when it selects none.
+func (p responseProfile) project(content map[string]interface{}) map[string]interface{} {
+	if p.projection == nil {
+		return content
+	}
+	return projectFields(content, p.projection)
+}
+
+func profileFrom(ctx context.Context) responseProfile {
+	p, _ := ctx.Value(profileKey).(responseProfile)
+	return p
+}
+
+// projectFields returns the fields of the content selected by the projection, leaving the content unchanged.
+// The projection has the nested maps form of filterKeys: a map selects fields of a map or of the maps of an array,
+// while any other value selects the whole field.
+func projectFields(content map[string]interface{}, projection map[string]interface{}) map[string]interface{} {
+	projected := make(map[string]interface{}, len(projection))
+	for key, selection := range projection {
+		if _, found := content[key]; !found {
+			continue
+		}
+		nested, isNested := selection.(map[string]interface{})
+		if !isNested {
+			projected[key] = content[key]
+			continue
+		}
+		switch typedVal := content[key].(type) {
+		case map[string]interface{}:
+			projected[key] = projectFields(typedVal, nested)
+		case []interface{}:
+			values := make([]interface{}, 0, len(typedVal))
+			for _, v := range typedVal {
+				if vMap, isMap := v.(map[string]interface{}); isMap {
+					values = append(values, projectFields(vMap, nested))
+				}
+			}
+			projected[key] = values
+		}
+	}
+	return projected
+}
+
+// varyHeader lists the request headers the responses depend on, including the profile, access policy and
+// entitlement headers when they are used.
+func (h internalContentHandler) varyHeader() string {
+	headers := []string{"Accept"}
+	if len(h.serviceConfig.responseProfiles) > 0 && h.serviceConfig.profileHeader != "" {
+		headers = append(headers, h.serviceConfig.profileHeader)
+	}
+	if len(h.serviceConfig.accessPolicies) > 0 || len(h.serviceConfig.embargoPrivilegedPolicies) > 0 {
+		headers = append(headers, h.serviceConfig.accessPolicyHeader)
+	}
+	if h.serviceConfig.entitlementHeader != "" {
+		headers = append(headers, h.serviceConfig.entitlementHeader)
+	}
+	return strings.Join(headers, ", ")
+}
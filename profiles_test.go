@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResponseProfiles(t *testing.T) {
+	profiles, err := parseResponseProfiles(`{
+		"app": {"fields": ["id", "topper.headline"], "unrollContent": true, "keepEmpty": ["byline"], "format": "application/json", "apiKeys": ["app-key"]},
+		"web": {}
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app", "web"}, profileNames(profiles))
+	assert.Equal(t, map[string]interface{}{"id": "", "topper": map[string]interface{}{"headline": ""}}, profiles["app"].projection)
+	assert.True(t, *profiles["app"].UnrollContent)
+	assert.Nil(t, profiles["web"].projection)
+
+	_, err = parseResponseProfiles(`{"app": {"format": "application/pdf"}}`)
+	assert.Error(t, err)
+	_, err = parseResponseProfiles(`{"app": {"fields": ["topper."]}}`)
+	assert.Error(t, err)
+	_, err = parseResponseProfiles(`{"app": {"apiKeys": ["key"]}, "web": {"apiKeys": ["key"]}}`)
+	assert.Error(t, err)
+}
+
+func TestRequestProfile(t *testing.T) {
+	profiles, err := parseResponseProfiles(`{"app": {"format": "text/plain", "apiKeys": ["app-key"]}, "web": {"format": "text/html"}}`)
+	require.NoError(t, err)
+	h := internalContentHandler{serviceConfig: &serviceConfig{responseProfiles: profiles, profileHeader: "X-Api-Key"}}
+
+	r := httptest.NewRequest(http.MethodGet, "/internalcontent/1?profile=web", nil)
+	r.Header.Set("X-Api-Key", "app-key")
+	p, err := h.requestProfile(r)
+	require.NoError(t, err)
+	assert.Equal(t, "text/html", p.Format, "The profile parameter should take precedence over the header")
+
+	r = httptest.NewRequest(http.MethodGet, "/internalcontent/1", nil)
+	r.Header.Set("X-Api-Key", "app-key")
+	p, err = h.requestProfile(r)
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", p.Format, "The profile should be selected by the API key")
+
+	r = httptest.NewRequest(http.MethodGet, "/internalcontent/1", nil)
+	r.Header.Set("X-Api-Key", "other-key")
+	p, err = h.requestProfile(r)
+	require.NoError(t, err)
+	assert.Equal(t, responseProfile{}, p)
+
+	_, err = h.requestProfile(httptest.NewRequest(http.MethodGet, "/internalcontent/1?profile=unknown", nil))
+	assert.Error(t, err)
+
+	assert.Equal(t, "Accept, X-Api-Key", h.varyHeader())
+}
+
+func TestProfileSettingsAreOverriddenByTheRequest(t *testing.T) {
+	unroll := true
+	drop := true
+	profile := responseProfile{UnrollContent: &unroll, KeepEmpty: []string{"byline"}, DropEmptyArrays: &drop, Format: "text/plain"}
+	h := internalContentHandler{serviceConfig: &serviceConfig{}}
+
+	assert.True(t, profile.unrollContent(httptest.NewRequest(http.MethodGet, "/internalcontent/1", nil)))
+	assert.False(t, profile.unrollContent(httptest.NewRequest(http.MethodGet, "/internalcontent/1?unrollContent=false", nil)))
+	assert.False(t, responseProfile{}.unrollContent(httptest.NewRequest(http.MethodGet, "/internalcontent/1", nil)))
+
+	policy, err := h.requestEmptyFieldPolicy(httptest.NewRequest(http.MethodGet, "/internalcontent/1?keepEmpty=standfirst&dropEmptyArrays=false", nil), profile)
+	require.NoError(t, err)
+	assert.Equal(t, emptyFieldPolicy{keep: parseFieldPaths("byline,standfirst")}, policy)
+
+	r := httptest.NewRequest(http.MethodGet, "/internalcontent/1", nil)
+	r.Header.Set("Accept", "text/html")
+	assert.Equal(t, "text/plain", profile.renderer(r).mediaType)
+	assert.Equal(t, "text/html", responseProfile{}.renderer(r).mediaType)
+}
+
+func TestProjectFields(t *testing.T) {
+	projection, err := fieldPathsFilter([]string{"title", "topper.headline", "embeds.id", "annotations.id", "missing.field"})
+	require.NoError(t, err)
+	content := map[string]interface{}{
+		"title":       "Title",
+		"byline":      "Byline",
+		"topper":      map[string]interface{}{"headline": "Headline", "theme": "dark"},
+		"embeds":      []interface{}{map[string]interface{}{"id": "1", "title": "Embed"}, "not an embed"},
+		"annotations": []interface{}{map[string]interface{}{"id": "2", "predicate": "about"}},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"title":       "Title",
+		"topper":      map[string]interface{}{"headline": "Headline"},
+		"embeds":      []interface{}{map[string]interface{}{"id": "1"}},
+		"annotations": []interface{}{map[string]interface{}{"id": "2"}},
+	}, projectFields(content, projection))
+	assert.Equal(t, "Byline", content["byline"], "The content should be left unchanged")
+	assert.Equal(t, content, responseProfile{}.project(content))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	publishReferenceKey contextKey = "publishReference"
+	waitMsKey           contextKey = "waitMs"
+
+	initialPublishWaitBackoff = 100 * time.Millisecond
+	maxPublishWaitBackoff     = time.Second
+
+	staleCacheControl = "no-store"
+)
+
+// publishWait is the publish reference a request expects the content to have, and for how long to wait for it.
+type publishWait struct {
+	publishReference string
+	wait             time.Duration
+}
+
+func (h internalContentHandler) parsePublishWait(r *http.Request) (publishWait, error) {
+	wait := publishWait{publishReference: r.URL.Query().Get(publishReferenceKey.String())}
+	waitMs := r.URL.Query().Get(waitMsKey.String())
+	if waitMs == "" {
+		return wait, nil
+	}
+	ms, err := strconv.Atoi(waitMs)
+	if err != nil || ms < 0 {
+		return wait, fmt.Errorf("%s should be a positive number of milliseconds", waitMsKey)
+	}
+	wait.wait = time.Duration(ms) * time.Millisecond
+	if wait.wait > h.serviceConfig.maxPublishWait {
+		wait.wait = h.serviceConfig.maxPublishWait
+	}
+	return wait, nil
+}
+
+// waitForPublishReference retrieves the merged content until it has the expected publish reference or the wait expires,
+// backing off between the attempts. Content that is still stale at the end is returned with a 409 when no wait was
+// requested and a 504 when the wait expired. Failures are retried as the content may not be published yet,
+// except for deleted content.
+func (h internalContentHandler) waitForPublishReference(ctx context.Context, r *http.Request, wait publishWait, uuid string, tid string) (context.Context, map[string]interface{}, *Problem) {
+	deadline := time.Now().Add(wait.wait)
+	backoff := initialPublishWaitBackoff
+	for {
+		attemptCtx := context.WithValue(ctx, responseStateKey, newResponseState())
+		content, problem := h.retrieveAndMerge(attemptCtx, uuid, tid)
+		if wait.publishReference == "" || (problem == nil && content["publishReference"] == wait.publishReference) {
+			return attemptCtx, content, problem
+		}
+		if problem != nil && problem.Status == http.StatusGone {
+			return attemptCtx, content, problem
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			if problem == nil {
+				h.log.StalePublishReferenceEvent(wait.publishReference, content["publishReference"], tid, uuid)
+				responseStateFrom(attemptCtx).markStaleWithStatus(stalePublishStatus(wait))
+			}
+			return attemptCtx, content, problem
+		}
+		if backoff > remaining {
+			backoff = remaining
+		}
+		select {
+		case <-r.Context().Done():
+			deadline = time.Now()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxPublishWaitBackoff {
+			backoff = maxPublishWaitBackoff
+		}
+	}
+}
+
+func stalePublishStatus(wait publishWait) int {
+	if wait.wait > 0 {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusConflict
+}
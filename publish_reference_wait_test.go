@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePublishWait(t *testing.T) {
+	h := internalContentHandler{serviceConfig: &serviceConfig{maxPublishWait: 2 * time.Second}}
+	data := []struct {
+		name          string
+		query         string
+		expected      publishWait
+		expectedError bool
+	}{
+		{"no publish reference", "", publishWait{}, false},
+		{"publish reference without wait", "?publishReference=tid_1", publishWait{"tid_1", 0}, false},
+		{"publish reference with wait", "?publishReference=tid_1&waitMs=500", publishWait{"tid_1", 500 * time.Millisecond}, false},
+		{"wait is capped", "?publishReference=tid_1&waitMs=5000", publishWait{"tid_1", 2 * time.Second}, false},
+		{"wait is not a number", "?publishReference=tid_1&waitMs=soon", publishWait{}, true},
+		{"wait is negative", "?publishReference=tid_1&waitMs=-1", publishWait{}, true},
+	}
+
+	for _, row := range data {
+		wait, err := h.parsePublishWait(httptest.NewRequest(http.MethodGet, "/internalcontent/1"+row.query, nil))
+		if row.expectedError {
+			assert.Error(t, err, row.name)
+			continue
+		}
+		assert.NoError(t, err, row.name)
+		assert.Equal(t, row.expected, wait, row.name)
+	}
+}
+
+func TestStalePublishStatus(t *testing.T) {
+	assert.Equal(t, http.StatusConflict, stalePublishStatus(publishWait{"tid_1", 0}))
+	assert.Equal(t, http.StatusGatewayTimeout, stalePublishStatus(publishWait{"tid_1", time.Second}))
+}
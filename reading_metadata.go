@@ -0,0 +1,65 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+const wordsPerMinute = 200
+
+var imageTypes = []string{
+	"http://www.ft.com/ontology/content/ImageSet",
+	"http://www.ft.com/ontology/content/Image",
+	"http://www.ft.com/ontology/content/Graphic",
+}
+
+// addReadingMetadata computes the reading metadata fields from the bodyXML of the merged content.
+// The summaryText is cut on a word boundary to be at most summaryLength characters long.
+func addReadingMetadata(content map[string]interface{}, summaryLength int) {
+	bodyXML, ok := contentString(content, "bodyXML")
+	if !ok {
+		return
+	}
+	text := bodyText(bodyXML)
+	wordCount := len(strings.Fields(text))
+	imageCount, embedCount := countEmbeds(parseBodyTree(bodyXML))
+
+	content["wordCount"] = wordCount
+	content["readingTimeMinutes"] = int(math.Ceil(float64(wordCount) / wordsPerMinute))
+	content["imageCount"] = imageCount
+	content["embedCount"] = embedCount
+	content["summaryText"] = summarise(text, summaryLength)
+}
+
+func countEmbeds(n *bodyNode) (imageCount int, embedCount int) {
+	if n.name == "ft-content" && n.attrs["data-embedded"] == "true" {
+		embedCount++
+		for _, imageType := range imageTypes {
+			if n.attrs["type"] == imageType {
+				imageCount++
+				break
+			}
+		}
+	}
+	for _, child := range n.children {
+		images, embeds := countEmbeds(child)
+		imageCount += images
+		embedCount += embeds
+	}
+	return imageCount, embedCount
+}
+
+func summarise(text string, length int) string {
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	runes := []rune(text)
+	summary := string(runes[:length])
+	if runes[length] != ' ' {
+		if i := strings.LastIndex(summary, " "); i > 0 {
+			summary = summary[:i]
+		}
+	}
+	return strings.TrimSpace(summary) + "…"
+}
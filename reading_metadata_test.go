@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddReadingMetadata(t *testing.T) {
+	content := map[string]interface{}{
+		"bodyXML": `<body><ft-content data-embedded="true" type="http://www.ft.com/ontology/content/ImageSet" url="http://api.ft.com/content/1"></ft-content>` +
+			`<p>Lorem ipsum dolor sit amet, consectetur adipiscing elit.</p>` +
+			`<ft-content data-embedded="true" type="http://www.ft.com/ontology/content/DynamicContent" url="http://api.ft.com/content/2"></ft-content>` +
+			`<p>Sed <ft-content type="http://www.ft.com/ontology/content/Article" url="http://api.ft.com/content/3">feugiat</ft-content> turpis.</p></body>`,
+	}
+
+	addReadingMetadata(content, 30)
+
+	assert.Equal(t, 11, content["wordCount"])
+	assert.Equal(t, 1, content["readingTimeMinutes"])
+	assert.Equal(t, 1, content["imageCount"])
+	assert.Equal(t, 2, content["embedCount"])
+	assert.Equal(t, "Lorem ipsum dolor sit amet,…", content["summaryText"])
+}
+
+func TestAddReadingMetadataWithoutBody(t *testing.T) {
+	content := map[string]interface{}{"title": "title"}
+
+	addReadingMetadata(content, 30)
+
+	assert.Equal(t, map[string]interface{}{"title": "title"}, content)
+}
+
+func TestSummarise(t *testing.T) {
+	data := []struct {
+		text    string
+		length  int
+		summary string
+	}{
+		{"short text", 20, "short text"},
+		{"cut on the word boundary", 8, "cut on…"},
+		{"cut after a word", 9, "cut after…"},
+		{"Longwordwithoutspaces", 5, "Longw…"},
+	}
+
+	for _, row := range data {
+		assert.Equal(t, row.summary, summarise(row.text, row.length), row.text)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+	"github.com/gorilla/mux"
+	"golang.org/x/net/context"
+)
+
+// recoveryMiddleware turns a panic while serving a request into a 500, so that a single malformed payload
+// does not take the whole service down.
+func recoveryMiddleware(log *appLogger, metrics *Metrics) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					tid := transactionidutils.GetTransactionIDFromRequest(r)
+					log.PanicEvent(r.RequestURI, tid, recovered, debug.Stack())
+					metrics.recordErrorEvent()
+					writeProblem(w, newProblem(internalErrorProblem, http.StatusInternalServerError, "Unexpected error while serving the request", tid))
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// recoverRetrieval retrieves the content of a source, turning a panic while retrieving or transforming it
+// into a 502 naming the source when it is required and into a missing content when it is optional.
+func (h internalContentHandler) recoverRetrieval(ctx context.Context, r retriever, uuid string, tid string) (part responsePart) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			h.log.PanicEvent(r.uri, tid, recovered, debug.Stack())
+			h.metrics.recordErrorEvent()
+			part = responsePart{isOk: !r.doFail, statusCode: http.StatusBadGateway, upstream: r.sourceAppName}
+			if r.doFail {
+				part.failMsg = fmt.Sprintf("Failed to process the response of %s", r.sourceAppName)
+				part.failure = invalidUpstreamProblem
+			}
+		}
+	}()
+	part = h.retrieveWithFallbacks(ctx, r, uuid, tid)
+	part.version = contentVersion(part.content)
+	filter := h.sourceFilter(part.upstream)
+	part.content = filterKeys(r.transformContent(ctx, part.content, h), filter.fields)
+	// a configured embeds filter applies whether or not the embeds are merged with the ones of another source
+	if filter.Embeds != nil {
+		part.content = filterContentEmbeds(part.content, filter.Embeds)
+	}
+	part.embedsFilter = filter.Embeds
+	return part
+}
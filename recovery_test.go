@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	metrics := NewMetrics()
+	handler := recoveryMiddleware(newAppLogger(), &metrics)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var content map[string]interface{}
+		_ = content["leadImages"].([]interface{})
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var problem Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, problemTypeBaseURI+"internal-error", problem.Type)
+	assert.NotEmpty(t, problem.TransactionID)
+}
+
+func TestRecoverRetrieval(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "http://www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce"}`))
+	}))
+	defer upstream.Close()
+
+	metrics := NewMetrics()
+	h := internalContentHandler{&serviceConfig{httpClient: http.DefaultClient}, newAppLogger(), &metrics}
+	ctx := context.WithValue(context.Background(), uuidKey, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+	panicking := func(ctx context.Context, content map[string]interface{}, h internalContentHandler) map[string]interface{} {
+		_ = content["embeds"].([]interface{})
+		return content
+	}
+
+	required := h.recoverRetrieval(ctx, retriever{uri: upstream.URL + "/", sourceAppName: "required-source", doFail: true, transformContent: panicking}, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "tid_test")
+	assert.False(t, required.isOk)
+	assert.Equal(t, http.StatusBadGateway, required.statusCode)
+	assert.Equal(t, "required-source", required.upstream)
+	assert.Equal(t, invalidUpstreamProblem, required.failure)
+
+	optional := h.recoverRetrieval(ctx, retriever{uri: upstream.URL + "/", sourceAppName: "optional-source", doFail: false, transformContent: panicking}, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "tid_test")
+	assert.True(t, optional.isOk)
+	assert.Nil(t, optional.content)
+}
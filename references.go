@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	gouuid "github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"golang.org/x/net/context"
+)
+
+type contentReferences struct {
+	ID         string      `json:"id"`
+	References []reference `json:"references"`
+}
+
+type reference struct {
+	UUID string `json:"uuid"`
+	Type string `json:"type"`
+}
+
+var referenceFields = []struct {
+	field         string
+	referenceType string
+}{
+	{"embeds", "embed"},
+	{"leadImages", "leadImage"},
+	{"mainImage", "mainImage"},
+	{"alternativeImages", "alternativeImage"},
+	{"curatedRelatedContent", "curatedRelatedContent"},
+	{"containedIn", "containedIn"},
+	{"annotations", "annotation"},
+}
+
+// ServeReferences returns the uuids referenced by the merged content, typed by the field they were found in.
+func (h internalContentHandler) ServeReferences(w http.ResponseWriter, r *http.Request) {
+	ctx, mergedContent, ok := h.retrieveMergedContent(w, r, mux.Vars(r)["uuid"])
+	if !ok {
+		return
+	}
+	uuid := contentUUID(ctx)
+	visibleContent := h.visibleContent(ctx, r, mergedContent)
+	resultBytes, _ := json.Marshal(contentReferences{uuid, extractReferences(visibleContent)})
+	w.Header().Set("Vary", h.varyHeader())
+	h.setCacheHeaders(ctx, w, visibleContent)
+	w.WriteHeader(responseStateFrom(ctx).statusCode())
+	_, _ = w.Write(resultBytes)
+	h.metrics.recordResponseEvent()
+}
+
+// visibleContent returns the content the caller is entitled and allowed to see, from which the references and their
+// surrogate keys are taken so that they do not reveal what the truncation and the access policies withhold.
+func (h internalContentHandler) visibleContent(ctx context.Context, r *http.Request, content map[string]interface{}) map[string]interface{} {
+	return h.restrictFields(ctx, r, h.entitledContent(ctx, content))
+}
+
+func extractReferences(content map[string]interface{}) []reference {
+	references := []reference{}
+	seen := make(map[reference]bool)
+	add := func(id string, referenceType string) {
+		ref := reference{extractIDValue(id), referenceType}
+		if _, err := gouuid.Parse(ref.UUID); err != nil || seen[ref] {
+			return
+		}
+		seen[ref] = true
+		references = append(references, ref)
+	}
+
+	for _, f := range referenceFields {
+		for _, id := range collectIDs(content[f.field]) {
+			add(id, f.referenceType)
+		}
+	}
+	if bodyXML, ok := contentString(content, "bodyXML"); ok {
+		for _, id := range bodyContentURLs(parseBodyTree(bodyXML)) {
+			add(id, "bodyContent")
+		}
+	}
+	return references
+}
+
+// collectIDs returns the ids found in a field, which can be a plain id, an object with an id or a list or map of them.
+func collectIDs(value interface{}) []string {
+	var ids []string
+	switch typedValue := value.(type) {
+	case string:
+		ids = append(ids, typedValue)
+	case []interface{}:
+		for _, v := range typedValue {
+			ids = append(ids, collectIDs(v)...)
+		}
+	case map[string]interface{}:
+		if id, ok := typedValue["id"].(string); ok {
+			return append(ids, id)
+		}
+		for _, k := range sortedKeys(typedValue) {
+			ids = append(ids, collectIDs(typedValue[k])...)
+		}
+	}
+	return ids
+}
+
+func bodyContentURLs(n *bodyNode) []string {
+	var urls []string
+	if n.name == "ft-content" && n.attrs["url"] != "" {
+		urls = append(urls, n.attrs["url"])
+	}
+	for _, child := range n.children {
+		urls = append(urls, bodyContentURLs(child)...)
+	}
+	return urls
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
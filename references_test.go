@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestExtractReferences(t *testing.T) {
+	content := map[string]interface{}{
+		"bodyXML": `<body><ft-content type="http://www.ft.com/ontology/content/ImageSet" url="http://api.ft.com/content/b7c8e40e-73a7-11e8-17fc-56e8d19c9a20"></ft-content><p>text</p></body>`,
+		"embeds": []interface{}{
+			map[string]interface{}{"id": "https://api.ft.com/content/66101830-3863-11ea-bfdf-938130fb4080"},
+			map[string]interface{}{"title": "embed without id"},
+		},
+		"leadImages": []interface{}{
+			map[string]interface{}{"id": "https://api.ft.com/content/f3add2e0-dbfa-11e6-a7d5-ce30ecef69c7", "type": "square"},
+			map[string]interface{}{"id": "https://api.ft.com/content/f3add2e0-dbfa-11e6-a7d5-ce30ecef69c7", "type": "wide"},
+		},
+		"mainImage": map[string]interface{}{"id": "http://www.ft.com/thing/da57d673-6a9a-46b6-beed-fd3797b02f73"},
+		"alternativeImages": map[string]interface{}{
+			"promotionalImage": map[string]interface{}{"id": "http://www.ft.com/thing/0c1a5cd8-11e4-4d5d-9b53-d0ba6d8e1ba6"},
+		},
+		"curatedRelatedContent": []interface{}{"http://www.ft.com/thing/2b6d9fbb-5d2a-4b2b-8bbf-6ae6dfcd3d17"},
+		"containedIn":           []interface{}{map[string]interface{}{"id": "http://www.ft.com/thing/6fa1a0c4-3a45-4d70-9c36-c1aa4f44fb8e"}},
+		"annotations": []interface{}{
+			map[string]interface{}{"id": "http://api.ft.com/things/a5dcd3e2-3645-3f79-a4f5-90c3a4679326", "prefLabel": "Feature"},
+		},
+		"brands": []interface{}{"http://api.ft.com/things/dbb0bdae-1f0c-11e4-b0cb-b2227cce2b54"},
+	}
+
+	expected := []reference{
+		{"66101830-3863-11ea-bfdf-938130fb4080", "embed"},
+		{"f3add2e0-dbfa-11e6-a7d5-ce30ecef69c7", "leadImage"},
+		{"da57d673-6a9a-46b6-beed-fd3797b02f73", "mainImage"},
+		{"0c1a5cd8-11e4-4d5d-9b53-d0ba6d8e1ba6", "alternativeImage"},
+		{"2b6d9fbb-5d2a-4b2b-8bbf-6ae6dfcd3d17", "curatedRelatedContent"},
+		{"6fa1a0c4-3a45-4d70-9c36-c1aa4f44fb8e", "containedIn"},
+		{"a5dcd3e2-3645-3f79-a4f5-90c3a4679326", "annotation"},
+		{"b7c8e40e-73a7-11e8-17fc-56e8d19c9a20", "bodyContent"},
+	}
+	assert.Equal(t, expected, extractReferences(content))
+}
+
+func TestExtractReferencesFromEmptyContent(t *testing.T) {
+	assert.Equal(t, []reference{}, extractReferences(map[string]interface{}{}))
+}
+
+func TestReferencesOfTheVisibleContent(t *testing.T) {
+	content := map[string]interface{}{
+		"bodyXML": `<body><p>First <ft-content type="http://www.ft.com/ontology/content/Article" url="http://api.ft.com/content/2b6d9fbb-5d2a-4b2b-8bbf-6ae6dfcd3d17">link</ft-content>.</p>` +
+			`<ft-content data-embedded="true" type="http://www.ft.com/ontology/content/ImageSet" url="http://api.ft.com/content/b7c8e40e-73a7-11e8-17fc-56e8d19c9a20"></ft-content><p>Second.</p></body>`,
+		"embeds":    []interface{}{map[string]interface{}{"id": "https://api.ft.com/content/b7c8e40e-73a7-11e8-17fc-56e8d19c9a20"}},
+		"mainImage": map[string]interface{}{"id": "http://www.ft.com/thing/da57d673-6a9a-46b6-beed-fd3797b02f73"},
+	}
+	h := internalContentHandler{serviceConfig: &serviceConfig{
+		accessPolicies:      accessPolicies{"mainImage": {"INTERNAL_UNSTABLE"}},
+		accessPolicyHeader:  "X-Policy",
+		truncatedParagraphs: 1,
+	}, log: newAppLogger()}
+	r := httptest.NewRequest(http.MethodGet, "/internalcontent/5c3cae78-dbef-11e6-9d7c-be108f1c1dce/references", nil)
+
+	entitled := context.WithValue(context.Background(), entitledKey, true)
+	assert.ElementsMatch(t, []reference{
+		{"b7c8e40e-73a7-11e8-17fc-56e8d19c9a20", "embed"},
+		{"2b6d9fbb-5d2a-4b2b-8bbf-6ae6dfcd3d17", "bodyContent"},
+		{"b7c8e40e-73a7-11e8-17fc-56e8d19c9a20", "bodyContent"},
+	}, extractReferences(h.visibleContent(entitled, r, content)), "Should leave out the fields the policies do not allow")
+
+	notEntitled := context.WithValue(context.Background(), entitledKey, false)
+	assert.Equal(t, []reference{
+		{"2b6d9fbb-5d2a-4b2b-8bbf-6ae6dfcd3d17", "bodyContent"},
+	}, extractReferences(h.visibleContent(notEntitled, r, content)), "Should leave out what the truncation withholds")
+
+	r.Header.Set("X-Policy", "INTERNAL_UNSTABLE")
+	assert.Contains(t, extractReferences(h.visibleContent(entitled, r, content)), reference{"da57d673-6a9a-46b6-beed-fd3797b02f73", "mainImage"})
+}
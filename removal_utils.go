@@ -1,53 +1,85 @@
 package main
 
-func removeEmptyMapFields(content map[string]interface{}) {
-	for key, val := range content {
-		switch typedVal := val.(type) {
+// removeEmptyMapFields returns the content without its nil, empty string and empty object fields, at any depth.
+// The content is left unchanged, only the maps and arrays holding empty fields being copied.
+func removeEmptyMapFields(content map[string]interface{}) map[string]interface{} {
+	return removeEmptyFields(content, emptyFieldPolicy{})
+}
 
-		case nil:
-			delete(content, key)
+// removeEmptyFields returns the content without the empty fields removed by the policy, leaving the content unchanged.
+func removeEmptyFields(content map[string]interface{}, policy emptyFieldPolicy) map[string]interface{} {
+	cleaned, _ := withoutEmptyMapFields(content, "", policy)
+	return cleaned
+}
 
-		case string:
-			if typedVal == "" {
-				delete(content, key)
-			}
+func withoutEmptyMapFields(content map[string]interface{}, path string, policy emptyFieldPolicy) (map[string]interface{}, bool) {
+	var cleaned map[string]interface{}
+	for key, val := range content {
+		value, empty, changed := withoutEmptyValues(val, policy.fieldPath(path, key), policy)
+		if !empty && !changed {
+			continue
+		}
+		if cleaned == nil {
+			cleaned = copyMap(content)
+		}
+		if empty {
+			delete(cleaned, key)
+		} else {
+			cleaned[key] = value
+		}
+	}
+	if cleaned == nil {
+		return content, false
+	}
+	return cleaned, true
+}
 
-		case map[string]interface{}:
-			removeEmptyMapFields(typedVal)
-			if len(typedVal) == 0 {
-				delete(content, key)
+func removeEmptySliceValues(slice []interface{}, path string, policy emptyFieldPolicy) ([]interface{}, bool) {
+	var cleaned []interface{}
+	for i, elem := range slice {
+		value, empty, changed := withoutEmptyValues(elem, path, policy)
+		if cleaned == nil {
+			if !empty && !changed {
+				continue
 			}
-
-		case []interface{}:
-			content[key] = removeEmptySliceValues(typedVal)
+			cleaned = make([]interface{}, i, len(slice))
+			copy(cleaned, slice[:i])
+		}
+		if !empty {
+			cleaned = append(cleaned, value)
 		}
 	}
+	if cleaned == nil {
+		return slice, false
+	}
+	return cleaned, true
 }
 
-func removeEmptySliceValues(slice []interface{}) []interface{} {
-	length := len(slice)
-
-	for i := length - 1; i >= 0; i-- {
-		switch typedElem := slice[i].(type) {
+// withoutEmptyValues returns the value without its empty fields, whether the value is itself empty and removed by
+// the policy and whether it had to be copied.
+func withoutEmptyValues(val interface{}, path string, policy emptyFieldPolicy) (interface{}, bool, bool) {
+	if policy.isKept(path) {
+		switch val.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			return val, false, false
+		}
+	}
+	switch typedVal := val.(type) {
 
-		case nil:
-			slice = append(slice[:i], slice[i+1:]...)
+	case nil:
+		return nil, true, false
 
-		case string:
-			if typedElem == "" {
-				slice = append(slice[:i], slice[i+1:]...)
-			}
+	case string:
+		return val, typedVal == "", false
 
-		case map[string]interface{}:
-			removeEmptyMapFields(typedElem)
-			if len(typedElem) == 0 {
-				slice = append(slice[:i], slice[i+1:]...)
-			}
+	case map[string]interface{}:
+		cleaned, changed := withoutEmptyMapFields(typedVal, path, policy)
+		return cleaned, len(cleaned) == 0 && !policy.isKept(path), changed
 
-		case []interface{}:
-			slice[i] = removeEmptySliceValues(typedElem)
-		}
+	case []interface{}:
+		cleaned, changed := removeEmptySliceValues(typedVal, path, policy)
+		return cleaned, len(cleaned) == 0 && policy.dropEmptyArrays && !policy.isKept(path), changed
 	}
-
-	return slice
+	return val, false, false
 }
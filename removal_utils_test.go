@@ -20,7 +20,7 @@ func TestEmptyFieldRemoval_NilIsRemoved(t *testing.T) {
 		"nil":    nil,
 	}
 
-	removeEmptyMapFields(content)
+	content = removeEmptyMapFields(content)
 
 	assert.Equal(t, mapWithSingleValue, content)
 }
@@ -31,7 +31,7 @@ func TestEmptyFieldRemoval_EmptyStringIsRemoved(t *testing.T) {
 		"emptyString": "",
 	}
 
-	removeEmptyMapFields(content)
+	content = removeEmptyMapFields(content)
 
 	assert.Equal(t, mapWithSingleValue, content)
 }
@@ -42,7 +42,7 @@ func TestEmptyFieldRemoval_EmptyMapIsRemoved(t *testing.T) {
 		"emptyMap": map[string]interface{}{},
 	}
 
-	removeEmptyMapFields(content)
+	content = removeEmptyMapFields(content)
 
 	assert.Equal(t, mapWithSingleValue, content)
 }
@@ -55,7 +55,7 @@ func TestEmptyFieldRemoval_MapWithEmptyStringIsRemoved(t *testing.T) {
 		},
 	}
 
-	removeEmptyMapFields(content)
+	content = removeEmptyMapFields(content)
 
 	assert.Equal(t, mapWithSingleValue, content)
 }
@@ -66,7 +66,7 @@ func TestEmptyFieldRemoval_EmptyArrayIsNotRemoved(t *testing.T) {
 		"slice":  []interface{}{},
 	}
 
-	removeEmptyMapFields(content)
+	content = removeEmptyMapFields(content)
 
 	assert.Equal(t, mapWithValueAndEmptySlice, content)
 }
@@ -77,7 +77,7 @@ func TestEmptyFieldRemoval_ArrayWithNilIsEmptied(t *testing.T) {
 		"slice":  []interface{}{nil},
 	}
 
-	removeEmptyMapFields(content)
+	content = removeEmptyMapFields(content)
 
 	assert.Equal(t, mapWithValueAndEmptySlice, content)
 }
@@ -88,7 +88,7 @@ func TestEmptyFieldRemoval_ArrayWithEmptyValueIsEmptied(t *testing.T) {
 		"slice":  []interface{}{""},
 	}
 
-	removeEmptyMapFields(content)
+	content = removeEmptyMapFields(content)
 
 	assert.Equal(t, mapWithValueAndEmptySlice, content)
 }
@@ -101,7 +101,7 @@ func TestEmptyFieldRemoval_SliceWithEmptyMapIsEmptied(t *testing.T) {
 		},
 	}
 
-	removeEmptyMapFields(content)
+	content = removeEmptyMapFields(content)
 
 	assert.Equal(t, mapWithValueAndEmptySlice, content)
 }
@@ -116,7 +116,7 @@ func TestEmptyFieldRemoval_SliceWithMapWithNilIsEmptied(t *testing.T) {
 		},
 	}
 
-	removeEmptyMapFields(content)
+	content = removeEmptyMapFields(content)
 
 	assert.Equal(t, mapWithValueAndEmptySlice, content)
 }
@@ -207,7 +207,7 @@ func TestEmptyFieldRemoval_complexCase(t *testing.T) {
 		},
 	}
 
-	removeEmptyMapFields(content)
+	content = removeEmptyMapFields(content)
 
 	assert.Equal(t, expected, content)
 }
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type renderContent func(content map[string]interface{}) ([]byte, error)
+
+type renderer struct {
+	mediaType   string
+	contentType string
+	render      renderContent
+}
+
+var jsonRenderer = renderer{"application/json", "application/json; charset=utf-8", renderJSON}
+
+var renderers = []renderer{
+	jsonRenderer,
+	{"application/ld+json", "application/ld+json; charset=utf-8", renderJSONLD},
+	{"text/plain", "text/plain; charset=utf-8", renderPlainText},
+	{"text/html", "text/html; charset=utf-8", renderHTMLPreview},
+	{nitfMediaType, nitfMediaType + "; charset=utf-8", renderNITF},
+}
+
+type acceptedMediaType struct {
+	mediaType string
+	quality   float64
+}
+
+// rendererFor returns the renderer of the media type.
+func rendererFor(mediaType string) (renderer, bool) {
+	for _, r := range renderers {
+		if r.mediaType == mediaType {
+			return r, true
+		}
+	}
+	return renderer{}, false
+}
+
+// negotiateRenderer picks the renderer for the most preferred media type of the Accept header, falling back to JSON.
+func negotiateRenderer(accept string) renderer {
+	for _, accepted := range parseAccept(accept) {
+		if accepted.mediaType == "*/*" {
+			return jsonRenderer
+		}
+		for _, r := range renderers {
+			if r.mediaType == accepted.mediaType {
+				return r
+			}
+		}
+		if strings.HasSuffix(accepted.mediaType, "/*") {
+			for _, r := range renderers {
+				if strings.HasPrefix(r.mediaType, strings.TrimSuffix(accepted.mediaType, "*")) {
+					return r
+				}
+			}
+		}
+	}
+	return jsonRenderer
+}
+
+func parseAccept(accept string) []acceptedMediaType {
+	var accepted []acceptedMediaType
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, found := params["q"]; found {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		accepted = append(accepted, acceptedMediaType{mediaType, quality})
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+	return accepted
+}
+
+func renderJSON(content map[string]interface{}) ([]byte, error) {
+	return marshalJSON(content)
+}
+
+func renderJSONLD(content map[string]interface{}) ([]byte, error) {
+	article := map[string]interface{}{
+		"@context": "https://schema.org",
+		"@type":    "NewsArticle",
+	}
+	setIfPresent(article, "identifier", content["id"])
+	setIfPresent(article, "headline", content["title"])
+	setIfPresent(article, "description", content["standfirst"])
+	setIfPresent(article, "datePublished", content["publishedDate"])
+	setIfPresent(article, "dateModified", content["lastModified"])
+	setIfPresent(article, "url", content["webUrl"])
+	setIfPresent(article, "mainEntityOfPage", content["webUrl"])
+	if byline, ok := content["byline"].(string); ok && byline != "" {
+		article["author"] = map[string]interface{}{"@type": "Person", "name": byline}
+	}
+	if bodyXML, ok := contentString(content, "bodyXML"); ok && bodyXML != "" {
+		article["articleBody"] = bodyText(bodyXML)
+	}
+	if images := contentImageIDs(content); len(images) > 0 {
+		article["image"] = images
+	}
+	if accessLevel, ok := content["accessLevel"].(string); ok {
+		article["isAccessibleForFree"] = accessLevel == "free"
+	}
+	article["publisher"] = map[string]interface{}{"@type": "Organization", "name": "Financial Times"}
+	return json.Marshal(article)
+}
+
+func setIfPresent(m map[string]interface{}, key string, value interface{}) {
+	if s, ok := value.(string); ok && s != "" {
+		m[key] = s
+	}
+}
+
+func contentImageIDs(content map[string]interface{}) []string {
+	var ids []string
+	if mainImage, ok := content["mainImage"].(map[string]interface{}); ok {
+		if id, ok := mainImage["id"].(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	leadImages, _ := content["leadImages"].([]interface{})
+	for _, l := range leadImages {
+		leadImage, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, ok := leadImage["id"].(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func renderPlainText(content map[string]interface{}) ([]byte, error) {
+	var blocks []string
+	for _, key := range []string{"title", "standfirst", "byline"} {
+		if s, ok := content[key].(string); ok && strings.TrimSpace(s) != "" {
+			blocks = append(blocks, strings.TrimSpace(s))
+		}
+	}
+	if bodyXML, ok := contentString(content, "bodyXML"); ok {
+		blocks = append(blocks, bodyParagraphs(bodyXML)...)
+	}
+	return []byte(strings.Join(blocks, "\n\n") + "\n"), nil
+}
+
+var htmlPreviewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<article>
+{{- with .Topper}}
+<header class="topper{{with .theme}} topper--{{.}}{{end}}"{{with .bgColor}} data-bg-color="{{.}}"{{end}}>
+{{- with .headline}}
+<h1>{{.}}</h1>
+{{- end}}
+{{- with .standfirst}}
+<p class="topper__standfirst">{{.}}</p>
+{{- end}}
+</header>
+{{- end}}
+<h1>{{.Title}}</h1>
+{{- with .Standfirst}}
+<p class="standfirst">{{.}}</p>
+{{- end}}
+{{- with .Byline}}
+<p class="byline">{{.}}</p>
+{{- end}}
+{{- with .PublishedDate}}
+<time datetime="{{.}}">{{.}}</time>
+{{- end}}
+<div class="body">{{.Body}}</div>
+</article>
+</body>
+</html>
+`))
+
+type htmlPreview struct {
+	Title         string
+	Standfirst    string
+	Byline        string
+	PublishedDate string
+	Topper        map[string]interface{}
+	Body          template.HTML
+}
+
+func renderHTMLPreview(content map[string]interface{}) ([]byte, error) {
+	preview := htmlPreview{}
+	preview.Title, _ = content["title"].(string)
+	preview.Standfirst, _ = content["standfirst"].(string)
+	preview.Byline, _ = content["byline"].(string)
+	preview.PublishedDate, _ = content["publishedDate"].(string)
+	preview.Topper, _ = content["topper"].(map[string]interface{})
+	if bodyXML, ok := contentString(content, "bodyXML"); ok {
+		// bodyXML is published by our own editorial systems, so it is trusted to be rendered as is
+		preview.Body = template.HTML(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(bodyXML), "<body>"), "</body>"))
+	}
+
+	var buf bytes.Buffer
+	err := htmlPreviewTemplate.Execute(&buf, preview)
+	return buf.Bytes(), err
+}
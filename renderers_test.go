@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var renderedContent = map[string]interface{}{
+	"id":            "http://www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce",
+	"title":         "Lorem ipsum",
+	"standfirst":    "Curabitur accumsan",
+	"byline":        "By Jane Doe",
+	"publishedDate": "2014-01-29T12:39:06.000Z",
+	"accessLevel":   "subscribed",
+	"bodyXML":       "<body><p>First <em>paragraph</em>.</p>\n<h2>Heading</h2><p>Second\n paragraph.</p></body>",
+	"topper": map[string]interface{}{
+		"headline": "Topper headline",
+		"theme":    "split-text-left",
+	},
+	"leadImages": []interface{}{
+		map[string]interface{}{"id": "https://api.ft.com/content/1", "type": "square"},
+	},
+}
+
+func TestNegotiateRenderer(t *testing.T) {
+	data := []struct {
+		accept            string
+		expectedMediaType string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/json", "application/json"},
+		{"application/ld+json", "application/ld+json"},
+		{"text/plain", "text/plain"},
+		{"text/html,application/xhtml+xml;q=0.9,*/*;q=0.8", "text/html"},
+		{"text/plain;q=0.5, text/html", "text/html"},
+		{"text/*", "text/plain"},
+		{"image/png", "application/json"},
+		{"text/html;q=0, text/plain", "text/plain"},
+	}
+
+	for _, row := range data {
+		assert.Equal(t, row.expectedMediaType, negotiateRenderer(row.accept).mediaType, "Accept: %s", row.accept)
+	}
+}
+
+func TestRenderJSONLD(t *testing.T) {
+	res, err := renderJSONLD(renderedContent)
+	assert.NoError(t, err)
+
+	var article map[string]interface{}
+	assert.NoError(t, json.Unmarshal(res, &article))
+	assert.Equal(t, "NewsArticle", article["@type"])
+	assert.Equal(t, "Lorem ipsum", article["headline"])
+	assert.Equal(t, "First paragraph. Heading Second paragraph.", article["articleBody"])
+	assert.Equal(t, map[string]interface{}{"@type": "Person", "name": "By Jane Doe"}, article["author"])
+	assert.Equal(t, []interface{}{"https://api.ft.com/content/1"}, article["image"])
+	assert.Equal(t, false, article["isAccessibleForFree"])
+}
+
+func TestRenderPlainText(t *testing.T) {
+	res, err := renderPlainText(renderedContent)
+	assert.NoError(t, err)
+	assert.Equal(t, "Lorem ipsum\n\nCurabitur accumsan\n\nBy Jane Doe\n\nFirst paragraph.\n\nHeading\n\nSecond paragraph.\n", string(res))
+}
+
+func TestRenderHTMLPreview(t *testing.T) {
+	res, err := renderHTMLPreview(renderedContent)
+	assert.NoError(t, err)
+	assert.Contains(t, string(res), `<header class="topper topper--split-text-left">`)
+	assert.Contains(t, string(res), "<h1>Topper headline</h1>")
+	assert.Contains(t, string(res), "<p>First <em>paragraph</em>.</p>")
+	assert.NotContains(t, string(res), "<body><p>")
+}
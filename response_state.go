@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+const responseStateKey contextKey = "responseState"
+
+// responseState records how complete and fresh the response of a request is while it is being built.
+type responseState struct {
+	mu           sync.Mutex
+	partial      bool
+	stale        bool
+	status       int
+	inconsistent bool
+	embargoed    bool
+	source       string
+}
+
+func newResponseState() *responseState {
+	return &responseState{status: http.StatusOK}
+}
+
+func responseStateFrom(ctx context.Context) *responseState {
+	state, ok := ctx.Value(responseStateKey).(*responseState)
+	if !ok {
+		return newResponseState()
+	}
+	return state
+}
+
+func (s *responseState) markPartial() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.partial = true
+}
+
+func (s *responseState) isPartial() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.partial
+}
+
+func (s *responseState) isStale() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stale
+}
+
+// markStaleWithStatus marks the response as stale and to be returned with the given status code.
+func (s *responseState) markStaleWithStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stale = true
+	s.status = status
+}
+
+func (s *responseState) statusCode() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *responseState) setContentSource(source string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.source = source
+}
+
+func (s *responseState) contentSource() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.source
+}
+
+func (s *responseState) markInconsistent() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inconsistent = true
+}
+
+// markEmbargoed marks the response as holding content under embargo, which must not be cached.
+func (s *responseState) markEmbargoed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.embargoed = true
+}
+
+func (s *responseState) isEmbargoed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.embargoed
+}
+
+func (s *responseState) isInconsistent() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inconsistent
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readSharedContent(t *testing.T, path string) map[string]interface{} {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	var content map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &content))
+	return content
+}
+
+// TestConcurrentMergesOfSharedContent shares the same upstream results between concurrent merges, as a cache of the
+// upstream responses would. It is meant to be run with the race detector, which reports any write to the shared maps.
+func TestConcurrentMergesOfSharedContent(t *testing.T) {
+	for _, pair := range [][2]string{
+		{"test-resources/enriched-content-api-output.json", "test-resources/content-public-read-output.json"},
+		{"test-resources/embedded-enrichedcontent-output.json", "test-resources/embedded-internalcomponents-output.json"},
+	} {
+		content := readSharedContent(t, pair[0])
+		internalComponents := readSharedContent(t, pair[1])
+		expectedContent := readSharedContent(t, pair[0])
+		expectedInternalComponents := readSharedContent(t, pair[1])
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				filtered := filterKeys(replaceUUID(internalComponents), internalComponentsFilter)
+				merged, err := mergeParts([]responsePart{{content: content}, {content: filtered}}, testBaseURL)
+				if !assert.NoError(t, err) {
+					return
+				}
+				merged["requestUrl"] = "http://api.ft.com/internalcontent/1"
+				removeEmptyMapFields(renameKey("requestUrl", "apiUrl", mergeTwoContents(content, internalComponents)))
+				removeEmptyMapFields(merged)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, expectedContent, content, "the shared content should not be modified")
+		assert.Equal(t, expectedInternalComponents, internalComponents, "the shared internal components should not be modified")
+	}
+}
+
+func TestCopyOnWriteOperationsLeaveTheirInputUnchanged(t *testing.T) {
+	input := func() map[string]interface{} {
+		return map[string]interface{}{
+			"uuid":  "1",
+			"title": "",
+			"embeds": []interface{}{
+				map[string]interface{}{"uuid": "2", "requestUrl": "http://api.ft.com/content/2", "lastModified": "2017-01-01"},
+			},
+			"topper": map[string]interface{}{"headline": "", "requestUrl": "http://api.ft.com/content/1"},
+		}
+	}
+	content := input()
+
+	assert.Equal(t, map[string]interface{}{"headline": "", "apiUrl": "http://api.ft.com/content/1"}, renameKey("requestUrl", "apiUrl", content)["topper"])
+	assert.Equal(t, "1", replaceUUID(content)["id"])
+	assert.NotContains(t, filterKeys(content, map[string]interface{}{"topper": map[string]interface{}{"headline": nil}})["topper"], "headline")
+	assert.NotContains(t, removeEmptyMapFields(content), "title")
+	merged, err := mergeParts([]responsePart{{content: content}, {content: input()}}, testBaseURL)
+	require.NoError(t, err)
+	assert.Equal(t, testBaseURL+"2", merged["embeds"].([]interface{})[0].(map[string]interface{})["id"])
+	assert.Equal(t, "", mergeTwoContents(content, input())["title"])
+
+	assert.Equal(t, input(), content)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// sourceFilter tells which fields are removed from the content of a source before it is merged. Fields holds paths
+// such as topper.internalNote, Embeds the fields removed from each of the embeds of the source.
+type sourceFilter struct {
+	Fields []string `json:"fields"`
+	Embeds []string `json:"embeds"`
+	// fields is the filterKeys form of Fields
+	fields map[string]interface{}
+}
+
+// parseSourceFilters parses the filters of the sources, given as a JSON object keyed by the source app names.
+// A configured source replaces the default filters of the source, so that no embed field is removed
+// when its embeds filter is not given.
+func parseSourceFilters(filters string) (map[string]sourceFilter, error) {
+	var parsed map[string]sourceFilter
+	if filters == "" {
+		return parsed, nil
+	}
+	if err := json.Unmarshal([]byte(filters), &parsed); err != nil {
+		return nil, err
+	}
+	for appName, f := range parsed {
+		fields, err := fieldPathsFilter(f.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter of %s: %v", appName, err)
+		}
+		f.fields = fields
+		if f.Embeds == nil {
+			f.Embeds = []string{}
+		}
+		parsed[appName] = f
+	}
+	return parsed, nil
+}
+
+// fieldPathsFilter turns field paths into the nested maps of filterKeys, where a map filters the fields of a map
+// while any other value removes the whole field. A path therefore removes the fields below it given by longer paths.
+func fieldPathsFilter(paths []string) (map[string]interface{}, error) {
+	filter := make(map[string]interface{})
+	for _, path := range paths {
+		keys := strings.Split(path, ".")
+		level := filter
+		for i, key := range keys {
+			if key == "" {
+				return nil, fmt.Errorf("invalid field path %q", path)
+			}
+			if i == len(keys)-1 {
+				level[key] = ""
+				break
+			}
+			next, isMap := level[key].(map[string]interface{})
+			if !isMap {
+				if _, removed := level[key]; removed {
+					break
+				}
+				next = make(map[string]interface{})
+				level[key] = next
+			}
+			level = next
+		}
+	}
+	return filter, nil
+}
+
+// sourceFilter returns the filter of the source, which by default removes the versions and identifiers
+// of the internal components and the request URLs of the embeds.
+func (h internalContentHandler) sourceFilter(appName string) sourceFilter {
+	if f, configured := h.serviceConfig.sourceFilters[appName]; configured {
+		return f
+	}
+	if appName == h.serviceConfig.internalComponents.appName {
+		return sourceFilter{fields: internalComponentsFilter}
+	}
+	return sourceFilter{}
+}
+
+// filterContentEmbeds returns the content without the fields of the filter in each of its embeds,
+// leaving the given content unchanged.
+func filterContentEmbeds(content map[string]interface{}, filter []string) map[string]interface{} {
+	embeds, ok := content["embeds"].([]interface{})
+	if !ok || len(filter) == 0 {
+		return content
+	}
+	filtered := make([]interface{}, len(embeds))
+	for i, embed := range embeds {
+		if m, ok := embed.(map[string]interface{}); ok {
+			filtered[i] = filterEmbedsKeys(m, filter)
+		} else {
+			filtered[i] = embed
+		}
+	}
+	content = copyMap(content)
+	content["embeds"] = filtered
+	return content
+}
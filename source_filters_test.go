@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestFieldPathsFilter(t *testing.T) {
+	filter, err := fieldPathsFilter([]string{"id", "topper.internalNote", "topper.layout.theme", "alternativeTitles", "alternativeTitles.internal"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":                "",
+		"topper":            map[string]interface{}{"internalNote": "", "layout": map[string]interface{}{"theme": ""}},
+		"alternativeTitles": "",
+	}, filter)
+
+	_, err = fieldPathsFilter([]string{"topper..internalNote"})
+	assert.Error(t, err)
+}
+
+func TestFilterKeysWithFieldPaths(t *testing.T) {
+	filter, err := fieldPathsFilter([]string{"uuid", "topper.internalNote"})
+	require.NoError(t, err)
+	content := map[string]interface{}{
+		"uuid":   "5c3cae78-dbef-11e6-9d7c-be108f1c1dce",
+		"title":  "Title",
+		"topper": map[string]interface{}{"headline": "Headline", "internalNote": "Not for readers"},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"title":  "Title",
+		"topper": map[string]interface{}{"headline": "Headline"},
+	}, filterKeys(content, filter))
+}
+
+func TestParseSourceFilters(t *testing.T) {
+	filters, err := parseSourceFilters(`{"content-public-read": {"fields": ["id", "topper.internalNote"]}}`)
+	require.NoError(t, err)
+	f := filters["content-public-read"]
+	assert.Equal(t, map[string]interface{}{"id": "", "topper": map[string]interface{}{"internalNote": ""}}, f.fields)
+	assert.Equal(t, []string{}, f.Embeds, "A configured source without embeds filter should not filter its embeds")
+
+	filters, err = parseSourceFilters("")
+	assert.NoError(t, err)
+	assert.Nil(t, filters)
+
+	_, err = parseSourceFilters(`{"content-public-read": {"fields": [""]}}`)
+	assert.Error(t, err)
+	_, err = parseSourceFilters(`["content-public-read"]`)
+	assert.Error(t, err)
+}
+
+func TestDefaultSourceFilters(t *testing.T) {
+	h := internalContentHandler{serviceConfig: &serviceConfig{internalComponents: externalService{appName: "content-public-read"}}}
+
+	assert.Equal(t, internalComponentsFilter, h.sourceFilter("content-public-read").fields)
+	assert.Nil(t, h.sourceFilter("content-public-read").Embeds)
+	assert.Nil(t, h.sourceFilter("enriched-content-read-api").fields)
+}
+
+func TestRetrievalAppliesTheFilterOfTheSource(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"uuid": "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "topper": {"headline": "Headline", "internalNote": "Not for readers"},
+			"embeds": [{"id": "http://www.ft.com/thing/1", "title": "Embed", "lastModified": "2017-01-01"}]}`))
+	}))
+	defer upstream.Close()
+
+	filters, err := parseSourceFilters(`{"internal-source": {"fields": ["topper.internalNote"], "embeds": ["requestUrl", "lastModified"]}}`)
+	require.NoError(t, err)
+	metrics := NewMetrics()
+	h := internalContentHandler{&serviceConfig{httpClient: http.DefaultClient, sourceFilters: filters}, newAppLogger(), &metrics}
+	ctx := context.WithValue(context.Background(), uuidKey, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+
+	part := h.recoverRetrieval(ctx, retriever{uri: upstream.URL + "/", sourceAppName: "internal-source", transformContent: transformContentSourceContent}, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", "tid_test")
+	assert.Equal(t, map[string]interface{}{"headline": "Headline"}, part.content["topper"])
+	assert.Equal(t, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", part.content["uuid"])
+	assert.Equal(t, []string{"requestUrl", "lastModified"}, part.embedsFilter)
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": "http://www.ft.com/thing/1", "title": "Embed"}}, part.content["embeds"],
+		"The embeds should be filtered even when they are not merged with the ones of another source")
+
+	merged, err := mergeParts([]responsePart{part}, testBaseURL)
+	require.NoError(t, err)
+	assert.Equal(t, part.content["embeds"], merged["embeds"])
+}
+
+func TestMergePartsFiltersTheEmbedsOfEachSource(t *testing.T) {
+	parts := []responsePart{
+		{content: map[string]interface{}{"embeds": []interface{}{
+			map[string]interface{}{"id": "http://www.ft.com/thing/1", "requestUrl": "http://api.ft.com/content/1", "lastModified": "2017-01-01"},
+		}}},
+		{content: map[string]interface{}{"embeds": []interface{}{
+			map[string]interface{}{"id": "http://www.ft.com/thing/2", "requestUrl": "http://api.ft.com/content/2", "lastModified": "2017-01-02"},
+		}}, embedsFilter: []string{"lastModified"}},
+	}
+
+	merged, err := mergeParts(parts, testBaseURL)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": testBaseURL + "1", "lastModified": "2017-01-01"},
+		map[string]interface{}{"id": testBaseURL + "2", "apiUrl": "http://api.ft.com/content/2"},
+	}, merged["embeds"])
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	gouuid "github.com/google/uuid"
+	"golang.org/x/net/context"
+)
+
+var unrolledFields = []string{"embeds", "leadImages", "mainImage", "alternativeImages"}
+
+// surrogateKeys returns the uuid of the content followed by every uuid it references, including the ones of the
+// unrolled images and embeds, so that the CDN can purge the content when any of them changes.
+func surrogateKeys(uuid string, content map[string]interface{}) []string {
+	keys := []string{uuid}
+	seen := map[string]bool{uuid: true}
+	add := func(id string) {
+		key := extractIDValue(id)
+		if _, err := gouuid.Parse(key); err != nil || seen[key] {
+			return
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+
+	for _, ref := range extractReferences(content) {
+		add(ref.UUID)
+	}
+	for _, field := range unrolledFields {
+		for _, id := range collectNestedIDs(content[field]) {
+			add(id)
+		}
+	}
+	return keys
+}
+
+func collectNestedIDs(value interface{}) []string {
+	var ids []string
+	switch typedValue := value.(type) {
+	case []interface{}:
+		for _, v := range typedValue {
+			ids = append(ids, collectNestedIDs(v)...)
+		}
+	case map[string]interface{}:
+		for _, key := range []string{"id", "apiUrl"} {
+			if id, ok := typedValue[key].(string); ok {
+				ids = append(ids, id)
+			}
+		}
+		for _, k := range sortedKeys(typedValue) {
+			ids = append(ids, collectNestedIDs(typedValue[k])...)
+		}
+	}
+	return ids
+}
+
+func (h internalContentHandler) setCacheHeaders(ctx context.Context, w http.ResponseWriter, content map[string]interface{}) {
+	w.Header().Set("Surrogate-Key", strings.Join(surrogateKeys(contentUUID(ctx), content), " "))
+	if responseStateFrom(ctx).isEmbargoed() {
+		w.Header().Set("Cache-Control", embargoCacheControl)
+		return
+	}
+	// the stale 409 and 504 are retried by the publishing tools until the content is fresh, so they are not cached
+	if responseStateFrom(ctx).isStale() {
+		w.Header().Set("Cache-Control", staleCacheControl)
+		return
+	}
+	cacheControl := selectCacheControl(h.serviceConfig.cacheControlRules, h.serviceConfig.cacheControlPolicy, content, responseStateFrom(ctx), time.Now())
+	w.Header().Set("Cache-Control", cacheControl)
+	if h.serviceConfig.surrogateControlPolicy != "" {
+		w.Header().Set("Surrogate-Control", h.serviceConfig.surrogateControlPolicy)
+	}
+}
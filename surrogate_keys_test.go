@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestSurrogateKeys(t *testing.T) {
+	content := map[string]interface{}{
+		"id": "http://www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce",
+		"embeds": []interface{}{
+			map[string]interface{}{
+				"id": "https://api.ft.com/content/66101830-3863-11ea-bfdf-938130fb4080",
+				"members": []interface{}{
+					map[string]interface{}{"id": "https://api-t.ft.com/content/2ab480b4-72c4-4cd0-8598-ff72773b36f5"},
+				},
+			},
+		},
+		"leadImages": []interface{}{
+			map[string]interface{}{
+				"id":    "https://api.ft.com/content/bc5db2a2-22e8-11e8-8d6c-a1920d9e946f",
+				"image": map[string]interface{}{"apiUrl": "https://api.ft.com/content/bc5db2a2-22e8-11e8-8d6c-a1920d9e946f"},
+			},
+		},
+		"annotations": []interface{}{
+			map[string]interface{}{"id": "http://api.ft.com/things/a5dcd3e2-3645-3f79-a4f5-90c3a4679326"},
+		},
+		"bodyXML": `<body><ft-content url="http://api.ft.com/content/5c3cae78-dbef-11e6-9d7c-be108f1c1dce"></ft-content></body>`,
+	}
+
+	expected := []string{
+		"5c3cae78-dbef-11e6-9d7c-be108f1c1dce",
+		"66101830-3863-11ea-bfdf-938130fb4080",
+		"bc5db2a2-22e8-11e8-8d6c-a1920d9e946f",
+		"a5dcd3e2-3645-3f79-a4f5-90c3a4679326",
+		"2ab480b4-72c4-4cd0-8598-ff72773b36f5",
+	}
+	assert.Equal(t, expected, surrogateKeys("5c3cae78-dbef-11e6-9d7c-be108f1c1dce", content))
+}
+
+func TestSetCacheHeaders(t *testing.T) {
+	data := []struct {
+		name                     string
+		surrogateControlPolicy   string
+		expectedSurrogateControl string
+	}{
+		{"surrogate control is set when configured", "max-age=3600, stale-while-revalidate=60", "max-age=3600, stale-while-revalidate=60"},
+		{"surrogate control is not set by default", "", ""},
+	}
+
+	for _, row := range data {
+		h := internalContentHandler{serviceConfig: &serviceConfig{cacheControlPolicy: "max-age=10", surrogateControlPolicy: row.surrogateControlPolicy}}
+		w := httptest.NewRecorder()
+
+		ctx := context.WithValue(context.Background(), uuidKey, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+
+		h.setCacheHeaders(ctx, w, map[string]interface{}{})
+
+		assert.Equal(t, "max-age=10", w.Header().Get("Cache-Control"), row.name)
+		assert.Equal(t, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", w.Header().Get("Surrogate-Key"), row.name)
+		assert.Equal(t, row.expectedSurrogateControl, w.Header().Get("Surrogate-Control"), row.name)
+	}
+}
+
+func TestSetCacheHeadersOfStaleContent(t *testing.T) {
+	h := internalContentHandler{serviceConfig: &serviceConfig{cacheControlPolicy: "max-age=10", surrogateControlPolicy: "max-age=86400"}}
+	w := httptest.NewRecorder()
+	state := newResponseState()
+	state.markStaleWithStatus(http.StatusConflict)
+	ctx := context.WithValue(context.Background(), uuidKey, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+	ctx = context.WithValue(ctx, responseStateKey, state)
+
+	h.setCacheHeaders(ctx, w, map[string]interface{}{})
+
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Empty(t, w.Header().Get("Surrogate-Control"))
+	assert.Equal(t, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce", w.Header().Get("Surrogate-Key"))
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"time"
+)
+
+const nitfMediaType = "application/nitf+xml"
+
+var errNotSyndicatable = errors.New("content cannot be syndicated")
+
+type nitfDocument struct {
+	XMLName xml.Name `xml:"nitf"`
+	Version string   `xml:"version,attr"`
+	Head    nitfHead `xml:"head"`
+	Body    nitfBody `xml:"body"`
+}
+
+type nitfHead struct {
+	Title   string      `xml:"title"`
+	DocData nitfDocData `xml:"docdata"`
+}
+
+type nitfDocData struct {
+	DocID       nitfDocID     `xml:"doc-id"`
+	DateIssue   *nitfDate     `xml:"date.issue,omitempty"`
+	DateRelease *nitfDate     `xml:"date.release,omitempty"`
+	KeyList     *nitfKeyList  `xml:"key-list,omitempty"`
+	DocRights   nitfDocRights `xml:"doc.rights"`
+}
+
+type nitfDocID struct {
+	IDString string `xml:"id-string,attr"`
+}
+
+type nitfDate struct {
+	Norm string `xml:"norm,attr"`
+}
+
+type nitfKeyList struct {
+	Keywords []nitfKeyword `xml:"keyword"`
+}
+
+type nitfKeyword struct {
+	Key string `xml:"key,attr"`
+}
+
+type nitfDocRights struct {
+	Owner string `xml:"owner,attr"`
+}
+
+type nitfBody struct {
+	BodyHead    nitfBodyHead    `xml:"body.head"`
+	BodyContent nitfBodyContent `xml:"body.content"`
+	BodyEnd     struct{}        `xml:"body.end"`
+}
+
+type nitfBodyHead struct {
+	Hedline  nitfHedline   `xml:"hedline"`
+	Byline   string        `xml:"byline,omitempty"`
+	Abstract *nitfAbstract `xml:"abstract,omitempty"`
+}
+
+type nitfHedline struct {
+	HL1 string `xml:"hl1"`
+}
+
+type nitfAbstract struct {
+	Paragraphs []string `xml:"p"`
+}
+
+type nitfBodyContent struct {
+	Elements []interface{}
+}
+
+type nitfParagraph struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+}
+
+type nitfMedia struct {
+	XMLName        xml.Name           `xml:"media"`
+	MediaType      string             `xml:"media-type,attr"`
+	MediaReference nitfMediaReference `xml:"media-reference"`
+}
+
+type nitfMediaReference struct {
+	Source string `xml:"source,attr"`
+}
+
+// renderNITF maps the merged content into a NITF 3.6 document for the syndication partners.
+// Content that is not explicitly syndicatable is refused with errNotSyndicatable.
+func renderNITF(content map[string]interface{}) ([]byte, error) {
+	if canBeSyndicated, _ := content["canBeSyndicated"].(string); canBeSyndicated != "yes" {
+		return nil, errNotSyndicatable
+	}
+
+	doc := nitfDocument{Version: "-//IPTC//DTD NITF 3.6//EN"}
+	doc.Head.Title, _ = content["title"].(string)
+	if id, ok := content["id"].(string); ok {
+		doc.Head.DocData.DocID.IDString = extractIDValue(id)
+	}
+	doc.Head.DocData.DateIssue = nitfNormDate(content["firstPublishedDate"])
+	doc.Head.DocData.DateRelease = nitfNormDate(content["publishedDate"])
+	doc.Head.DocData.KeyList = nitfKeywords(content["annotations"])
+	doc.Head.DocData.DocRights.Owner = "Financial Times"
+
+	doc.Body.BodyHead.Hedline.HL1 = doc.Head.Title
+	if byline, ok := content["byline"].(string); ok {
+		doc.Body.BodyHead.Byline = strings.TrimSpace(byline)
+	}
+	if standfirst, ok := content["standfirst"].(string); ok && standfirst != "" {
+		doc.Body.BodyHead.Abstract = &nitfAbstract{[]string{standfirst}}
+	}
+
+	leadImages, _ := content["leadImages"].([]interface{})
+	for _, l := range leadImages {
+		leadImage, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, ok := leadImage["id"].(string); ok {
+			doc.Body.BodyContent.Elements = append(doc.Body.BodyContent.Elements, nitfMedia{MediaType: "image", MediaReference: nitfMediaReference{id}})
+		}
+	}
+	if bodyXML, ok := contentString(content, "bodyXML"); ok {
+		for _, block := range bodyBlocks(bodyXML) {
+			doc.Body.BodyContent.Elements = append(doc.Body.BodyContent.Elements, nitfParagraph{xml.Name{Local: nitfElement(block.element)}, block.text})
+		}
+	}
+
+	res, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), res...), nil
+}
+
+func nitfElement(bodyElement string) string {
+	switch bodyElement {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		return "hl2"
+	default:
+		return "p"
+	}
+}
+
+func nitfNormDate(value interface{}) *nitfDate {
+	date, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return nil
+	}
+	return &nitfDate{t.UTC().Format("20060102T150405Z")}
+}
+
+func nitfKeywords(value interface{}) *nitfKeyList {
+	annotations, _ := value.([]interface{})
+	var keywords []nitfKeyword
+	for _, a := range annotations {
+		annotation, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if prefLabel, ok := annotation["prefLabel"].(string); ok && prefLabel != "" {
+			keywords = append(keywords, nitfKeyword{prefLabel})
+		}
+	}
+	if len(keywords) == 0 {
+		return nil
+	}
+	return &nitfKeyList{keywords}
+}
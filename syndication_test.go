@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderNITF(t *testing.T) {
+	content := map[string]interface{}{
+		"id":                 "http://www.ft.com/thing/5c3cae78-dbef-11e6-9d7c-be108f1c1dce",
+		"title":              "Lorem & ipsum",
+		"standfirst":         "Curabitur accumsan",
+		"byline":             "By Jane Doe ",
+		"firstPublishedDate": "2014-01-29T12:39:06.000Z",
+		"publishedDate":      "2014-01-30T12:39:06.000Z",
+		"canBeSyndicated":    "yes",
+		"bodyXML":            "<body><p>First paragraph.</p><h2>Heading</h2><p>Second paragraph.</p></body>",
+		"annotations": []interface{}{
+			map[string]interface{}{"prefLabel": "Feature"},
+		},
+		"leadImages": []interface{}{
+			map[string]interface{}{"id": "https://api.ft.com/content/1"},
+		},
+	}
+
+	res, err := renderNITF(content)
+	assert.NoError(t, err)
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<nitf version="-//IPTC//DTD NITF 3.6//EN">
+  <head>
+    <title>Lorem &amp; ipsum</title>
+    <docdata>
+      <doc-id id-string="5c3cae78-dbef-11e6-9d7c-be108f1c1dce"></doc-id>
+      <date.issue norm="20140129T123906Z"></date.issue>
+      <date.release norm="20140130T123906Z"></date.release>
+      <key-list>
+        <keyword key="Feature"></keyword>
+      </key-list>
+      <doc.rights owner="Financial Times"></doc.rights>
+    </docdata>
+  </head>
+  <body>
+    <body.head>
+      <hedline>
+        <hl1>Lorem &amp; ipsum</hl1>
+      </hedline>
+      <byline>By Jane Doe</byline>
+      <abstract>
+        <p>Curabitur accumsan</p>
+      </abstract>
+    </body.head>
+    <body.content>
+      <media media-type="image">
+        <media-reference source="https://api.ft.com/content/1"></media-reference>
+      </media>
+      <p>First paragraph.</p>
+      <hl2>Heading</hl2>
+      <p>Second paragraph.</p>
+    </body.content>
+    <body.end></body.end>
+  </body>
+</nitf>`
+	assert.Equal(t, expected, string(res))
+}
+
+func TestRenderNITFRefusesContentThatCannotBeSyndicated(t *testing.T) {
+	for _, canBeSyndicated := range []interface{}{"no", "verify", nil} {
+		_, err := renderNITF(map[string]interface{}{"title": "title", "canBeSyndicated": canBeSyndicated})
+		assert.Equal(t, errNotSyndicatable, err, "canBeSyndicated: %v", canBeSyndicated)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	entitledKey     contextKey = "entitled"
+	freeAccessLevel            = "free"
+)
+
+// isEntitled tells whether the caller is entitled to the full content, which it is unless the trusted
+// entitlement header set by the API gateway says otherwise.
+func (h internalContentHandler) isEntitled(r *http.Request) bool {
+	if h.serviceConfig.entitlementHeader == "" {
+		return true
+	}
+	entitled, err := strconv.ParseBool(r.Header.Get(h.serviceConfig.entitlementHeader))
+	return err != nil || entitled
+}
+
+func isEntitledFrom(ctx context.Context) bool {
+	entitled, found := ctx.Value(entitledKey).(bool)
+	return !found || entitled
+}
+
+// entitledContent returns the content the caller is entitled to, truncated for the callers which are not.
+func (h internalContentHandler) entitledContent(ctx context.Context, content map[string]interface{}) map[string]interface{} {
+	if isEntitledFrom(ctx) {
+		return content
+	}
+	return truncateContent(content, h.serviceConfig.truncatedParagraphs)
+}
+
+// truncateContent returns the content for a caller which is not entitled to it: the body is truncated to its first
+// paragraphs, the embeds are removed and truncated is set when anything was withheld. Free content is left whole.
+func truncateContent(content map[string]interface{}, paragraphs int) map[string]interface{} {
+	if accessLevel, _ := content["accessLevel"].(string); accessLevel == freeAccessLevel {
+		return content
+	}
+	truncated := copyMap(content)
+	withheld := false
+	if bodyXML, ok := contentString(content, "bodyXML"); ok {
+		truncated["bodyXML"], withheld = truncateBodyXML(bodyXML, paragraphs)
+	}
+	if embeds, found := content["embeds"]; found {
+		delete(truncated, "embeds")
+		withheld = withheld || embeds != nil
+	}
+	if withheld {
+		truncated["truncated"] = true
+	}
+	return truncated
+}
+
+// paragraphElements are the elements counted as paragraphs of the body, the ones nested in them not being counted.
+var paragraphElements = map[string]bool{
+	"p":  true,
+	"li": true,
+}
+
+// truncateBodyXML keeps the markup of the body up to the end of its paragraphs-th paragraph, at any depth, so that
+// no paragraph is split, closing the elements left open, and removes the embedded content from it. The markup which
+// is kept is copied as it is. A body whose paragraphs cannot be counted is withheld whole. It tells whether anything
+// was removed.
+func truncateBodyXML(bodyXML string, paragraphs int) (string, bool) {
+	var out strings.Builder
+	decoder := newBodyXMLDecoder(bodyXML)
+	// open holds the elements written and not closed yet
+	var open []string
+	hasBody := false
+	hasContent := false
+	depth := 0
+	embedDepth := 0
+	paragraphDepth := 0
+	kept := 0
+	withheld := false
+	var offset int64
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		start := offset
+		offset = decoder.InputOffset()
+		raw := bodyXML[start:offset]
+
+		if embedDepth > 0 {
+			switch token.(type) {
+			case xml.StartElement:
+				depth++
+			case xml.EndElement:
+				if depth == embedDepth {
+					embedDepth = 0
+				}
+				depth--
+			}
+			continue
+		}
+
+		closedParagraph := false
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 && t.Name.Local == "body" {
+				hasBody = true
+			} else {
+				hasContent = true
+			}
+			if isEmbeddedContent(t) {
+				embedDepth = depth
+				withheld = true
+				continue
+			}
+			if paragraphDepth == 0 && paragraphElements[t.Name.Local] {
+				paragraphDepth = depth
+			}
+			open = append(open, t.Name.Local)
+		case xml.EndElement:
+			if depth == paragraphDepth {
+				paragraphDepth = 0
+				kept++
+				closedParagraph = true
+			}
+			depth--
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		case xml.CharData:
+			if strings.TrimSpace(string(t)) != "" {
+				hasContent = true
+			}
+		}
+		out.WriteString(raw)
+
+		if closedParagraph && kept >= paragraphs {
+			if hasMoreContent(decoder) {
+				withheld = true
+			}
+			for i := len(open) - 1; i >= 0; i-- {
+				out.WriteString("</" + open[i] + ">")
+			}
+			return out.String(), withheld
+		}
+	}
+	// nothing is given away from a body whose paragraphs could not be counted
+	if kept == 0 && hasContent {
+		if hasBody {
+			return "<body></body>", true
+		}
+		return "", true
+	}
+	return out.String(), withheld
+}
+
+func isEmbeddedContent(t xml.StartElement) bool {
+	if t.Name.Local != "ft-content" {
+		return false
+	}
+	for _, attr := range t.Attr {
+		if attr.Name.Local == "data-embedded" && attr.Value == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMoreContent tells whether the rest of the body holds any element or text.
+func hasMoreContent(decoder *xml.Decoder) bool {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			return true
+		case xml.CharData:
+			if strings.TrimSpace(string(t)) != "" {
+				return true
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+const truncatedBodyXML = `<body><p>First <a href="http://www.ft.com/1">paragraph</a>.</p>` +
+	`<ft-content data-embedded="true" type="http://www.ft.com/ontology/content/ImageSet" url="http://api.ft.com/content/1"></ft-content>` +
+	`<h2>Heading</h2><p>Second <ft-content type="http://www.ft.com/ontology/content/Article" url="http://api.ft.com/content/2">link</ft-content> paragraph.</p>` +
+	`<p>Third paragraph.</p><p>Fourth paragraph.</p></body>`
+
+func TestTruncateBodyXML(t *testing.T) {
+	testCases := []struct {
+		name       string
+		bodyXML    string
+		paragraphs int
+		expected   string
+		withheld   bool
+	}{
+		{
+			name:       "keeps the first paragraphs and removes the embeds",
+			bodyXML:    truncatedBodyXML,
+			paragraphs: 2,
+			expected: `<body><p>First <a href="http://www.ft.com/1">paragraph</a>.</p>` +
+				`<h2>Heading</h2><p>Second <ft-content type="http://www.ft.com/ontology/content/Article" url="http://api.ft.com/content/2">link</ft-content> paragraph.</p></body>`,
+			withheld: true,
+		},
+		{
+			name:       "keeps a short body whole",
+			bodyXML:    `<body><p>Only paragraph.</p>  </body>`,
+			paragraphs: 2,
+			expected:   `<body><p>Only paragraph.</p>  </body>`,
+		},
+		{
+			name:       "withholds an embed of a short body",
+			bodyXML:    `<body><p>Only paragraph.</p><ft-content data-embedded="true" url="http://api.ft.com/content/1"/></body>`,
+			paragraphs: 2,
+			expected:   `<body><p>Only paragraph.</p></body>`,
+			withheld:   true,
+		},
+		{
+			name:       "counts the paragraphs at any depth",
+			bodyXML:    `<body><blockquote><p>Quoted.</p><p>Quoted again.</p></blockquote><p>First.</p><p>Second.</p></body>`,
+			paragraphs: 1,
+			expected:   `<body><blockquote><p>Quoted.</p></blockquote></body>`,
+			withheld:   true,
+		},
+		{
+			name:       "closes the wrappers of the paragraphs",
+			bodyXML:    `<body><div class="wrapper"><p>one</p><p>two</p><p>three</p><p>four</p></div></body>`,
+			paragraphs: 2,
+			expected:   `<body><div class="wrapper"><p>one</p><p>two</p></div></body>`,
+			withheld:   true,
+		},
+		{
+			name:       "counts the list items as paragraphs",
+			bodyXML:    `<body><ul><li>one <p>nested</p></li><li>two</li><li>three</li></ul></body>`,
+			paragraphs: 2,
+			expected:   `<body><ul><li>one <p>nested</p></li><li>two</li></ul></body>`,
+			withheld:   true,
+		},
+		{
+			name:       "withholds a body without paragraphs",
+			bodyXML:    `<body><blockquote>Quoted text only.</blockquote></body>`,
+			paragraphs: 2,
+			expected:   `<body></body>`,
+			withheld:   true,
+		},
+		{
+			name:       "keeps an empty body",
+			bodyXML:    `<body></body>`,
+			paragraphs: 2,
+			expected:   `<body></body>`,
+		},
+		{
+			name:       "truncates a body without body element",
+			bodyXML:    `<p>First.</p><p>Second.</p>`,
+			paragraphs: 1,
+			expected:   `<p>First.</p>`,
+			withheld:   true,
+		},
+		{
+			name:       "keeps the entities as they are",
+			bodyXML:    `<body><p>Fish &amp; chips&nbsp;for two.</p><p>Second.</p></body>`,
+			paragraphs: 1,
+			expected:   `<body><p>Fish &amp; chips&nbsp;for two.</p></body>`,
+			withheld:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			truncated, withheld := truncateBodyXML(tc.bodyXML, tc.paragraphs)
+			assert.Equal(t, tc.expected, truncated)
+			assert.Equal(t, tc.withheld, withheld)
+		})
+	}
+}
+
+func TestTruncateContent(t *testing.T) {
+	content := map[string]interface{}{
+		"accessLevel": "subscribed",
+		"bodyXML":     truncatedBodyXML,
+		"embeds":      []interface{}{map[string]interface{}{"id": "http://api.ft.com/content/1"}},
+	}
+
+	truncated := truncateContent(content, 1)
+	assert.Equal(t, `<body><p>First <a href="http://www.ft.com/1">paragraph</a>.</p></body>`, truncated["bodyXML"])
+	assert.NotContains(t, truncated, "embeds")
+	assert.Equal(t, true, truncated["truncated"])
+	assert.Equal(t, truncatedBodyXML, content["bodyXML"], "The content should be left unchanged")
+
+	content["accessLevel"] = "free"
+	assert.Equal(t, content, truncateContent(content, 1), "Free content should not be truncated")
+
+	short := map[string]interface{}{"accessLevel": "subscribed", "bodyXML": "<body><p>Only paragraph.</p></body>"}
+	assert.NotContains(t, truncateContent(short, 2), "truncated", "Nothing should be marked as withheld")
+}
+
+func TestIsEntitled(t *testing.T) {
+	h := internalContentHandler{serviceConfig: &serviceConfig{entitlementHeader: "X-Entitled"}}
+
+	r := httptest.NewRequest(http.MethodGet, "/internalcontent/1", nil)
+	assert.True(t, h.isEntitled(r), "A caller without entitlement header should be entitled")
+	r.Header.Set("X-Entitled", "false")
+	assert.False(t, h.isEntitled(r))
+	r.Header.Set("X-Entitled", "true")
+	assert.True(t, h.isEntitled(r))
+	assert.Equal(t, "Accept, X-Entitled", h.varyHeader())
+
+	r.Header.Set("X-Entitled", "false")
+	h = internalContentHandler{serviceConfig: &serviceConfig{}}
+	assert.True(t, h.isEntitled(r), "The entitlement header should be ignored when it is not configured")
+}
+
+func TestResolveAdditionalFieldsOfTruncatedContent(t *testing.T) {
+	h := internalContentHandler{serviceConfig: &serviceConfig{envAPIHost: "api.ft.com", handlerPath: "internalcontent", truncatedParagraphs: 1}}
+	ctx := context.WithValue(context.Background(), uuidKey, "5c3cae78-dbef-11e6-9d7c-be108f1c1dce")
+	ctx = context.WithValue(ctx, entitledKey, false)
+	ctx = context.WithValue(ctx, bodyFormatKey, bodyFormatMarkdown)
+
+	content := h.resolveAdditionalFields(ctx, map[string]interface{}{"accessLevel": "subscribed", "bodyXML": truncatedBodyXML})
+	assert.Equal(t, true, content["truncated"])
+	assert.NotContains(t, content["bodyMarkdown"], "Second", "The fields computed from the body should only see the truncated body")
+}